@@ -206,7 +206,7 @@ func GetDutyUsers(ctx *ctx.Context, noticeData models.AlertNotice) []string {
 				us = append(us, fmt.Sprintf("@%s", user.DutyUserId))
 			}
 			return us
-		case "Email", "WeChat", "CustomHook":
+		case "Email", "WeChat", "CustomHook", "Teams", "Telegram":
 			for _, user := range users {
 				us = append(us, fmt.Sprintf("@%s", user.UserName))
 			}
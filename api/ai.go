@@ -26,6 +26,16 @@ func (aiController aiController) API(gin *gin.RouterGroup) {
 		a.POST("chat", aiController.Chat)
 	}
 
+	b := gin.Group("ai")
+	b.Use(
+		middleware.Auth(),
+		middleware.CasbinPermission(),
+		middleware.ParseTenant(),
+	)
+	{
+		b.GET("usage", aiController.Usage)
+	}
+
 }
 
 func (aiController aiController) Chat(ctx *gin.Context) {
@@ -38,6 +48,9 @@ func (aiController aiController) Chat(ctx *gin.Context) {
 		return
 	}
 
+	tid, _ := ctx.Get("TenantID")
+	r.TenantId = tid.(string)
+
 	// 设置 SSE 响应头进行流式传输
 	ctx.Header("Content-Type", "text/event-stream")
 	ctx.Header("Cache-Control", "no-cache")
@@ -56,14 +69,34 @@ func (aiController aiController) Chat(ctx *gin.Context) {
 	// 实时发送流式数据 - 这是真正的流式传输
 	// 注意：使用 map 包装 chunk，确保 Gin 进行 JSON 序列化
 	// 这样可以正确处理内容中的换行符等特殊字符
-	for chunk := range streamChan {
+	for event := range streamChan {
+		// 流式响应中断时发送 error 事件，告知前端已接收的内容不完整
+		if event.Err != nil {
+			ctx.SSEvent("error", map[string]string{"error": event.Err.Error()})
+			ctx.Writer.Flush()
+			break
+		}
+
 		// 每收到一个数据块立即发送给客户端
 		// 使用 map 包装，确保 JSON 序列化处理换行符
-		ctx.SSEvent("message", map[string]string{"content": chunk})
+		ctx.SSEvent("message", map[string]string{"content": event.Content})
 		// 立即刷新缓冲区，确保数据实时发送到客户端
 		ctx.Writer.Flush()
 	}
 
 	// 流式传输完成，无需发送额外结束信号
-	// 前端通过 ReadableStream 的 done 状态即可检测流结束
+	// 前端通过 ReadableStream 的 done 状态即可检测流结束，出错时会先收到 error 事件
+}
+
+// Usage 获取租户最近每天的 AI 调用用量与估算费用
+func (aiController aiController) Usage(ctx *gin.Context) {
+	r := new(types.RequestAiUsageQuery)
+	BindQuery(ctx, r)
+
+	tid, _ := ctx.Get("TenantID")
+	r.TenantId = tid.(string)
+
+	Service(ctx, func() (interface{}, interface{}) {
+		return services.AiService.Usage(r)
+	})
 }
@@ -98,6 +98,8 @@ func (consulController consulController) RegisterTarget(ctx *gin.Context) {
 			req.Job,
 			req.Tags,
 			req.Labels,
+			req.Check,
+			req.DatasourceId,
 		)
 	})
 }
@@ -144,14 +146,15 @@ func (consulController consulController) DeregisterTarget(ctx *gin.Context) {
 
 	// 解析注销原因
 	req := struct {
-		Reason string `json:"reason"`
+		Reason       string `json:"reason"`
+		DatasourceId string `json:"datasourceId"` // 可选，指定 Consul 数据源，默认使用目标注册时所在的数据源
 	}{}
 	if err := ctx.ShouldBindJSON(&req); err != nil {
 		// 注销原因是可选的，忽略解析错误
 	}
 
 	Service(ctx, func() (interface{}, interface{}) {
-		return services.ConsulService.DeregisterTarget(tenantId, id, req.Reason, userId)
+		return services.ConsulService.DeregisterTarget(tenantId, id, req.Reason, userId, req.DatasourceId)
 	})
 }
 
@@ -189,8 +192,13 @@ func (consulController consulController) SyncTargets(ctx *gin.Context) {
 		return
 	}
 
+	// 可选，指定要同步的 Consul 数据源，默认同步第一个 Consul 数据源
+	datasourceId := ctx.Query("datasourceId")
+	// 可选，dryRun=true 时只返回将要发生的变更，不写入数据库
+	dryRun := ctx.Query("dryRun") == "true"
+
 	Service(ctx, func() (interface{}, interface{}) {
-		return services.ConsulService.SyncTargets(tenantId)
+		return services.ConsulService.SyncTargets(tenantId, datasourceId, dryRun)
 	})
 }
 
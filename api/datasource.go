@@ -2,21 +2,27 @@ package api
 
 import (
 	ctx2 "alertHub/internal/ctx"
+	"alertHub/internal/global"
 	"alertHub/internal/middleware"
 	"alertHub/internal/models"
 	"alertHub/internal/services"
 	"alertHub/internal/types"
 	"alertHub/pkg/provider"
 	"alertHub/pkg/tools"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
 	"regexp"
+	"slices"
+	"sort"
 
 	"github.com/gin-gonic/gin"
 )
@@ -71,83 +77,75 @@ func parseVariablesFromQuery(ctx *gin.Context) map[string]string {
 	return variables
 }
 
+// promQLSelectorPattern 匹配带标签选择器的指标，如 node_cpu_seconds_total{mode="idle", instance="$instance"}
+var promQLSelectorPattern = regexp.MustCompile(`([a-zA-Z_:][a-zA-Z0-9_:]*)?\{([^}]*)\}`)
+
+// promQLVariableMatcherPattern 匹配标签选择器中引用变量的条件，如 instance="$instance"、pod=~"$pod"
+var promQLVariableMatcherPattern = regexp.MustCompile(`([a-zA-Z_][a-zA-Z0-9_]*)\s*(?:=~|!~|!=|=)\s*"\$([a-zA-Z_][a-zA-Z0-9_]*)"`)
+
 // autoFillMissingVariables 自动填充缺失的变量
-// 如果查询语句中包含 $instance 或 $ifName 但没有提供值，尝试从 Prometheus 获取
+// 扫描查询语句中的所有 $variable，对未提供值的变量尝试从 Prometheus 获取
 func autoFillMissingVariables(ctx *gin.Context, query string, datasourceId string, variables map[string]string) map[string]string {
 	result := make(map[string]string)
 	for k, v := range variables {
 		result[k] = v
 	}
 
-	// 检查查询语句中是否包含 $instance 或 $ifName
-	hasInstanceVar := strings.Contains(query, "$instance")
-	hasIfNameVar := strings.Contains(query, "$ifName")
+	for _, varName := range tools.ExtractVariablesFromPromQL(query) {
+		// 已提供值或 Grafana 内置变量（如 $__interval）不处理
+		if result[varName] != "" || strings.HasPrefix(varName, "__") {
+			continue
+		}
 
-	// 如果查询包含变量但没有提供值，尝试从 Prometheus 获取
-	if hasInstanceVar && result["instance"] == "" {
-		if instance := tryGetLabelValue(ctx, datasourceId, "instance", query); instance != "" {
-			result["instance"] = instance
+		labelName, metricName := resolveVariableSource(query, varName)
+		if value := tryGetLabelValue(ctx, datasourceId, labelName, metricName); value != "" {
+			result[varName] = value
 		}
 	}
 
-	if hasIfNameVar && result["ifName"] == "" {
-		if ifName := tryGetLabelValue(ctx, datasourceId, "ifName", query); ifName != "" {
-			result["ifName"] = ifName
+	return result
+}
+
+// resolveVariableSource 解析变量对应的 label 与 metric
+// 变量出现在 metric{label="$var"} 中时使用该 label 与 metric；否则 label 与变量同名，metric 为空
+func resolveVariableSource(query, varName string) (labelName, metricName string) {
+	for _, selector := range promQLSelectorPattern.FindAllStringSubmatch(query, -1) {
+		for _, matcher := range promQLVariableMatcherPattern.FindAllStringSubmatch(selector[2], -1) {
+			if matcher[2] == varName {
+				return matcher[1], selector[1]
+			}
 		}
 	}
 
-	return result
+	return varName, ""
 }
 
 // tryGetLabelValue 尝试从 Prometheus 获取 label 的第一个可用值
-// 通过查询包含该 label 的 metric 来获取值
-func tryGetLabelValue(ctx *gin.Context, datasourceId, labelName, originalQuery string) string {
+// 查询引用该变量的 metric，未指定 metric 时使用 up 兜底
+func tryGetLabelValue(ctx *gin.Context, datasourceId, labelName, metricName string) string {
 	source, err := ctx2.DO().DB.Datasource().Get(datasourceId)
 	if err != nil {
 		return ""
 	}
 
-	// 从原始查询中提取 metric 名称（例如：ifHCInMulticastPkts 或 ifInMulticastPkts）
-	// 使用正则表达式匹配 metric 名称
-	metricRe := regexp.MustCompile(`(ifHCIn\w+|ifIn\w+|ifOut\w+|ifHCOut\w+)`)
-	matches := metricRe.FindStringSubmatch(originalQuery)
-	if len(matches) == 0 {
-		// 如果没有找到，尝试查询 up metric
-		matches = []string{"up"}
+	if metricName == "" {
+		metricName = "up"
 	}
 
-	metricName := matches[0]
-	// 构建查询：查询该 metric 的所有时间序列，限制返回1个结果
+	// 构建查询：查询该 metric 中包含该 label 的时间序列，只取第一个结果
 	query := fmt.Sprintf("%s{%s=~\".+\"}", metricName, labelName)
 	fullURL := fmt.Sprintf("%s/api/v1/query?query=%s&time=%d",
 		source.HTTP.URL, url.QueryEscape(query), time.Now().Unix())
 
-	get, err := tools.Get(tools.CreateBasicAuthHeader(source.Auth.User, source.Auth.Pass), fullURL, 5)
-	if err != nil {
-		return ""
-	}
-	defer get.Body.Close()
-
-	if get.StatusCode != 200 {
-		return ""
-	}
-
-	var res provider.QueryResponse
-	if err := tools.ParseReaderBody(get.Body, &res); err != nil {
-		return ""
-	}
-
-	if res.Status != "success" || len(res.VMData.VMResult) == 0 {
+	res, err := requestPromQuery(source, fullURL, query)
+	if err != nil || len(res.VMData.VMResult) == 0 {
 		return ""
 	}
 
 	// 从第一个结果的 metric 标签中提取值
-	if len(res.VMData.VMResult) > 0 {
-		metricMap := res.VMData.VMResult[0].Metric
-		if value, exists := metricMap[labelName]; exists {
-			if valueStr, ok := value.(string); ok {
-				return valueStr
-			}
+	if value, exists := res.VMData.VMResult[0].Metric[labelName]; exists {
+		if valueStr, ok := value.(string); ok {
+			return valueStr
 		}
 	}
 
@@ -169,17 +167,40 @@ func replaceQueryVariables(query string, variables map[string]string) string {
 //
 // 返回: 注入过滤条件后的查询语句
 func injectInstanceFilter(query string, instances []string) string {
-	if len(instances) == 0 {
+	return injectLabelFilters(query, map[string][]string{"instance": instances})
+}
+
+// promQLKeywords 可能出现在查询中、但不是指标名称的关键字与聚合操作符
+var promQLKeywords = map[string]bool{
+	"bool": true, "and": true, "or": true, "unless": true, "offset": true,
+	"sum": true, "avg": true, "min": true, "max": true, "count": true, "group": true, "stddev": true,
+	"stdvar": true, "topk": true, "bottomk": true, "quantile": true, "count_values": true,
+}
+
+// promQLGroupingKeywords 其后括号内为标签名列表的关键字，如 sum by (job)
+var promQLGroupingKeywords = map[string]bool{
+	"by": true, "without": true, "on": true, "ignoring": true, "group_left": true, "group_right": true,
+}
+
+// promQLIdentPattern PromQL 标识符（指标名称、函数名、关键字）
+var promQLIdentPattern = regexp.MustCompile(`[a-zA-Z_:][a-zA-Z0-9_:]*`)
+
+// injectLabelFilters 向 PromQL 查询中注入多个标签的正则过滤条件
+// 参数:
+//   - query: 原始 PromQL 查询语句
+//   - matchers: 标签名 -> 允许的取值列表，同一标签的多个取值以 | 组合为正则，取值为空的标签忽略
+//
+// 返回: 注入过滤条件后的查询语句，例如 {"job": ["node"], "instance": ["h1", "h2"]} 注入 instance=~"h1|h2", job=~"node"
+// 取值按字面量匹配，其中的正则元字符、引号和反斜杠会被转义
+func injectLabelFilters(query string, matchers map[string][]string) string {
+	filter := buildLabelFilters(matchers)
+	if filter == "" {
 		return query
 	}
 
-	// 构建 instance 正则匹配表达式: instance=~"host1|host2|host3"
-	instanceRegex := strings.Join(instances, "|")
-	instanceFilter := fmt.Sprintf(`instance=~"%s"`, instanceRegex)
-
 	// 检查查询是否已包含大括号（标签选择器）
-	// 情况1: metric_name{existing_labels} -> metric_name{existing_labels, instance=~"..."}
-	// 情况2: metric_name -> metric_name{instance=~"..."}
+	// 情况1: metric_name{existing_labels} -> metric_name{existing_labels, label=~"..."}
+	// 情况2: metric_name -> metric_name{label=~"..."}
 	if strings.Contains(query, "{") {
 		// 已有标签选择器，在最后一个 } 前插入新的过滤条件
 		lastBraceIdx := strings.LastIndex(query, "}")
@@ -189,28 +210,106 @@ func injectInstanceFilter(query string, instances []string) string {
 			if firstBraceIdx >= 0 {
 				insideBraces := strings.TrimSpace(query[firstBraceIdx+1 : lastBraceIdx])
 				if insideBraces == "" {
-					// 空大括号: metric{} -> metric{instance=~"..."}
-					return query[:firstBraceIdx+1] + instanceFilter + query[lastBraceIdx:]
+					// 空大括号: metric{} -> metric{label=~"..."}
+					return query[:firstBraceIdx+1] + filter + query[lastBraceIdx:]
 				}
-				// 非空大括号: metric{label="value"} -> metric{label="value", instance=~"..."}
-				return query[:lastBraceIdx] + ", " + instanceFilter + query[lastBraceIdx:]
+				// 非空大括号: metric{label="value"} -> metric{label="value", label=~"..."}
+				return query[:lastBraceIdx] + ", " + filter + query[lastBraceIdx:]
 			}
 		}
 	}
 
 	// 没有标签选择器，需要找到指标名称的结束位置并添加
-	// 处理可能的函数调用: rate(metric[5m]) -> rate(metric{instance=~"..."}[5m])
-	// 简单情况: metric_name -> metric_name{instance=~"..."}
-	metricEndPattern := regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)`)
-	if match := metricEndPattern.FindStringIndex(query); match != nil {
-		metricEnd := match[1]
-		return query[:metricEnd] + "{" + instanceFilter + "}" + query[metricEnd:]
+	// 处理可能的函数调用: rate(metric[5m]) -> rate(metric{label=~"..."}[5m])
+	// 简单情况: metric_name -> metric_name{label=~"..."}
+	if metricEnd := findMetricNameEnd(query); metricEnd > 0 {
+		return query[:metricEnd] + "{" + filter + "}" + query[metricEnd:]
 	}
 
 	// 如果无法识别格式，返回原查询
 	return query
 }
 
+// buildLabelFilters 构建标签过滤条件，按标签名排序保证结果稳定
+func buildLabelFilters(matchers map[string][]string) string {
+	labels := make([]string, 0, len(matchers))
+	for label, values := range matchers {
+		if label != "" && len(values) > 0 {
+			labels = append(labels, label)
+		}
+	}
+	sort.Strings(labels)
+
+	filters := make([]string, 0, len(labels))
+	for _, label := range labels {
+		filters = append(filters, fmt.Sprintf(`%s=~%s`, label, quotePromQLRegexValues(matchers[label])))
+	}
+
+	return strings.Join(filters, ", ")
+}
+
+// quotePromQLRegexValues 将取值列表组合为按字面量匹配的正则，并转为 PromQL 字符串字面量
+// 例如 ["10.0.0.1:9100", `a"b`] -> "10\\.0\\.0\\.1:9100|a\"b"
+func quotePromQLRegexValues(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, regexp.QuoteMeta(v))
+	}
+	return strconv.Quote(strings.Join(quoted, "|"))
+}
+
+// findMetricNameEnd 查找查询中第一个指标名称的结束位置，未找到返回 -1
+// 跳过函数名（其后紧跟左括号）、关键字、时间单位（如 5m 中的 m）以及字符串中的内容
+func findMetricNameEnd(query string) int {
+	skipUntil := 0
+	for _, loc := range promQLIdentPattern.FindAllStringIndex(query, -1) {
+		start, end := loc[0], loc[1]
+
+		// 分组标签列表中的标签名，如 by (job) 中的 job
+		if start < skipUntil {
+			continue
+		}
+
+		// 时间单位或数字的一部分，如 [5m]、1e3
+		if start > 0 {
+			prev := query[start-1]
+			if prev >= '0' && prev <= '9' || prev == '.' {
+				continue
+			}
+		}
+
+		// 位于字符串中，如 label_replace 的参数
+		if strings.Count(query[:start], `"`)%2 == 1 {
+			continue
+		}
+
+		word := strings.ToLower(query[start:end])
+		if promQLGroupingKeywords[word] {
+			rest := strings.TrimLeft(query[end:], " ")
+			if strings.HasPrefix(rest, "(") {
+				open := len(query) - len(rest)
+				if closeIdx := strings.Index(query[open:], ")"); closeIdx >= 0 {
+					skipUntil = open + closeIdx
+				}
+			}
+			continue
+		}
+
+		if promQLKeywords[word] {
+			continue
+		}
+
+		// 函数调用: rate(...)、sum by (...)
+		if strings.HasPrefix(strings.TrimLeft(query[end:], " "), "(") {
+			continue
+		}
+
+		return end
+	}
+
+	return -1
+}
+
 // applyPagination 对查询结果应用分页
 // 在服务端对时间序列进行截取，减少返回给前端的数据量
 // 参数:
@@ -241,6 +340,38 @@ func applyPagination(results []provider.VMResult, limit, offset int) ([]provider
 	return results, total
 }
 
+// paginateResponses 对多个数据源的查询结果统一分页
+// 按数据源顺序合并全部时间序列后只分页一次，再将分页窗口拆回各数据源的响应
+// 返回: (分页后的各数据源响应, 合并后的总数)
+func paginateResponses(ress []provider.QueryResponse, limit, offset int) ([]provider.QueryResponse, int) {
+	var merged []provider.VMResult
+	for _, res := range ress {
+		merged = append(merged, res.VMData.VMResult...)
+	}
+
+	page, total := applyPagination(merged, limit, offset)
+
+	// 分页窗口在合并结果中的位置
+	start := min(max(offset, 0), total)
+	end := start + len(page)
+
+	paginated := make([]provider.QueryResponse, 0, len(ress))
+	pos := 0
+	for _, res := range ress {
+		count := len(res.VMData.VMResult)
+		lo, hi := max(start, pos)-pos, min(end, pos+count)-pos
+		if lo < hi {
+			res.VMData.VMResult = res.VMData.VMResult[lo:hi]
+		} else {
+			res.VMData.VMResult = []provider.VMResult{}
+		}
+		paginated = append(paginated, res)
+		pos += count
+	}
+
+	return paginated, total
+}
+
 /*
 数据源 API
 /api/w8t/datasource
@@ -268,6 +399,7 @@ func (datasourceController datasourceController) API(gin *gin.RouterGroup) {
 	{
 		b.GET("dataSourceList", datasourceController.List)
 		b.GET("dataSourceGet", datasourceController.Get)
+		b.GET("dataSourceHealth", datasourceController.Health)
 	}
 
 	c := gin.Group("datasource")
@@ -312,6 +444,18 @@ func (datasourceController datasourceController) List(ctx *gin.Context) {
 	})
 }
 
+func (datasourceController datasourceController) Health(ctx *gin.Context) {
+	r := new(types.RequestDatasourceHealth)
+	BindQuery(ctx, r)
+
+	tid, _ := ctx.Get("TenantID")
+	r.TenantId = tid.(string)
+
+	Service(ctx, func() (interface{}, interface{}) {
+		return services.DatasourceService.HealthCheck(r)
+	})
+}
+
 func (datasourceController datasourceController) Get(ctx *gin.Context) {
 	r := new(types.RequestDatasourceQuery)
 	BindQuery(ctx, r)
@@ -360,7 +504,7 @@ func (datasourceController datasourceController) PromQuery(ctx *gin.Context) {
 
 	Service(ctx, func() (interface{}, interface{}) {
 		// 自动填充缺失的变量（如果查询包含变量但没有提供值）
-		if len(variables) == 0 && (strings.Contains(r.Query, "$instance") || strings.Contains(r.Query, "$ifName")) {
+		if len(tools.ExtractVariablesFromPromQL(r.Query)) > 0 {
 			// 尝试从第一个数据源获取变量值
 			if len(strings.Split(r.DatasourceIds, ",")) > 0 {
 				firstDatasourceId := strings.Split(r.DatasourceIds, ",")[0]
@@ -386,64 +530,26 @@ func (datasourceController datasourceController) PromQuery(ctx *gin.Context) {
 		var ids = []string{}
 		ids = strings.Split(r.DatasourceIds, ",")
 		for _, id := range ids {
-			var res provider.QueryResponse
 			source, err := ctx2.DO().DB.Datasource().Get(id)
 			if err != nil {
 				return nil, err
 			}
 			fullURL := fmt.Sprintf("%s%s?%s", source.HTTP.URL, path, params.Encode())
 
-			get, err := tools.Get(tools.CreateBasicAuthHeader(source.Auth.User, source.Auth.Pass), fullURL, 10)
+			res, err := requestPromQuery(source, fullURL, query)
 			if err != nil {
-				return nil, fmt.Errorf("请求Prometheus失败: %w", err)
-			}
-			defer get.Body.Close()
-
-			// 检查HTTP状态码
-			if get.StatusCode != 200 {
-				return nil, fmt.Errorf("Prometheus返回非200状态码: %d, URL: %s", get.StatusCode, fullURL)
-			}
-
-			if err := tools.ParseReaderBody(get.Body, &res); err != nil {
-				return nil, fmt.Errorf("解析Prometheus响应失败: %w, URL: %s", err, fullURL)
-			}
-
-			// 检查Prometheus响应的status字段
-			if res.Status != "success" {
-				// Prometheus返回错误状态，即使HTTP状态码是200
-				errorMsg := fmt.Sprintf("Prometheus查询返回错误状态: %s, Query: %s", res.Status, query)
-				return nil, fmt.Errorf("%s, URL: %s", errorMsg, fullURL)
+				return nil, err
 			}
 
-			// 性能优化：应用服务端分页
-			if r.HasPagination() {
-				paginatedResults, total := applyPagination(res.VMData.VMResult, r.Limit, r.Offset)
-				res.VMData.VMResult = paginatedResults
-				// 将分页信息附加到响应中
-				ress = append(ress, res)
-				// 如果启用了分页，返回带分页元数据的响应
-				if len(ids) == 1 {
-					return types.PromQueryPaginatedResponse{
-						Data:   ress,
-						Total:  total,
-						Limit:  r.Limit,
-						Offset: r.Offset,
-					}, nil
-				}
-			} else {
-				ress = append(ress, res)
-			}
+			ress = append(ress, res)
 		}
 
-		// 如果启用了分页且有多个数据源，合并计算总数
-		if r.HasPagination() && len(ids) > 1 {
-			totalCount := 0
-			for _, res := range ress {
-				totalCount += len(res.VMData.VMResult)
-			}
+		// 性能优化：应用服务端分页，多数据源时先合并全部结果再分页，Total 为合并后的真实总数
+		if r.HasPagination() {
+			paginated, total := paginateResponses(ress, r.Limit, r.Offset)
 			return types.PromQueryPaginatedResponse{
-				Data:   ress,
-				Total:  totalCount,
+				Data:   paginated,
+				Total:  total,
 				Limit:  r.Limit,
 				Offset: r.Offset,
 			}, nil
@@ -467,7 +573,7 @@ func (datasourceController datasourceController) PromQueryRange(ctx *gin.Context
 		}
 
 		// 自动填充缺失的变量（如果查询包含变量但没有提供值）
-		if len(variables) == 0 && (strings.Contains(r.Query, "$instance") || strings.Contains(r.Query, "$ifName")) {
+		if len(tools.ExtractVariablesFromPromQL(r.Query)) > 0 {
 			// 尝试从第一个数据源获取变量值
 			if len(strings.Split(r.DatasourceIds, ",")) > 0 {
 				firstDatasourceId := strings.Split(r.DatasourceIds, ",")[0]
@@ -496,63 +602,26 @@ func (datasourceController datasourceController) PromQueryRange(ctx *gin.Context
 		ids = strings.Split(r.DatasourceIds, ",")
 
 		for _, id := range ids {
-			var res provider.QueryResponse
 			source, err := ctx2.DO().DB.Datasource().Get(id)
 			if err != nil {
 				return nil, err
 			}
 			fullURL := fmt.Sprintf("%s%s?%s", source.HTTP.URL, path, params.Encode())
 
-			get, err := tools.Get(tools.CreateBasicAuthHeader(source.Auth.User, source.Auth.Pass), fullURL, 10)
+			res, err := requestPromQuery(source, fullURL, query)
 			if err != nil {
-				return nil, fmt.Errorf("请求Prometheus失败: %w", err)
-			}
-			defer get.Body.Close()
-
-			// 检查HTTP状态码
-			if get.StatusCode != 200 {
-				return nil, fmt.Errorf("Prometheus返回非200状态码: %d, URL: %s", get.StatusCode, fullURL)
-			}
-
-			if err := tools.ParseReaderBody(get.Body, &res); err != nil {
-				return nil, fmt.Errorf("解析Prometheus响应失败: %w, URL: %s", err, fullURL)
-			}
-
-			// 检查Prometheus响应的status字段
-			if res.Status != "success" {
-				// Prometheus返回错误状态，即使HTTP状态码是200
-				errorMsg := fmt.Sprintf("Prometheus查询返回错误状态: %s, Query: %s", res.Status, query)
-				return nil, fmt.Errorf("%s, URL: %s", errorMsg, fullURL)
+				return nil, err
 			}
 
-			// 性能优化：应用服务端分页
-			if r.HasPagination() {
-				paginatedResults, total := applyPagination(res.VMData.VMResult, r.Limit, r.Offset)
-				res.VMData.VMResult = paginatedResults
-				ress = append(ress, res)
-				// 如果启用了分页且只有单个数据源，返回带分页元数据的响应
-				if len(ids) == 1 {
-					return types.PromQueryPaginatedResponse{
-						Data:   ress,
-						Total:  total,
-						Limit:  r.Limit,
-						Offset: r.Offset,
-					}, nil
-				}
-			} else {
-				ress = append(ress, res)
-			}
+			ress = append(ress, res)
 		}
 
-		// 如果启用了分页且有多个数据源，合并计算总数
-		if r.HasPagination() && len(ids) > 1 {
-			totalCount := 0
-			for _, res := range ress {
-				totalCount += len(res.VMData.VMResult)
-			}
+		// 性能优化：应用服务端分页，多数据源时先合并全部结果再分页，Total 为合并后的真实总数
+		if r.HasPagination() {
+			paginated, total := paginateResponses(ress, r.Limit, r.Offset)
 			return types.PromQueryPaginatedResponse{
-				Data:   ress,
-				Total:  totalCount,
+				Data:   paginated,
+				Total:  total,
 				Limit:  r.Limit,
 				Offset: r.Offset,
 			}, nil
@@ -562,87 +631,212 @@ func (datasourceController datasourceController) PromQueryRange(ctx *gin.Context
 	})
 }
 
+// requestPromQuery 请求 Prometheus 查询接口，失败时按 超时/连接/语句/认证 分类返回 *provider.QueryError
+func requestPromQuery(source models.AlertDataSource, fullURL, query string) (provider.QueryResponse, error) {
+	var res provider.QueryResponse
+
+	// 使用数据源配置的查询超时时间，较慢的范围查询可适当调大
+	c, cancel := context.WithTimeout(context.Background(), source.HTTP.GetQueryTimeout())
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(c, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return res, fmt.Errorf("创建Prometheus请求失败: %w", err)
+	}
+	for k, v := range tools.CreateBasicAuthHeader(source.Auth.User, source.Auth.Pass) {
+		request.Header.Set(k, v)
+	}
+
+	// 复用数据源的连接池，避免高并发查询时耗尽与 Prometheus 之间的 TCP 连接
+	get, err := provider.GetDatasourceHTTPClient(source.ID).Do(request)
+	if err != nil {
+		return res, provider.ClassifyRequestError(fmt.Errorf("请求Prometheus失败: %w", err))
+	}
+	defer func() {
+		// 读完响应体连接才能放回连接池
+		_, _ = io.Copy(io.Discard, get.Body)
+		get.Body.Close()
+	}()
+
+	// 非200时 Prometheus 仍会在响应体中返回 errorType，尽量解析用于分类
+	parseErr := tools.ParseReaderBody(get.Body, &res)
+	if get.StatusCode != http.StatusOK {
+		return res, provider.ClassifyResponseError(get.StatusCode, res.ErrorType,
+			fmt.Errorf("Prometheus返回非200状态码: %d, error: %s, Query: %s", get.StatusCode, res.Error, query))
+	}
+
+	if parseErr != nil {
+		return res, provider.ClassifyParseError(get.StatusCode, fmt.Errorf("解析Prometheus响应失败: %w, URL: %s", parseErr, fullURL))
+	}
+
+	// 检查Prometheus响应的status字段
+	if res.Status != "success" {
+		// Prometheus返回错误状态，即使HTTP状态码是200
+		return res, provider.ClassifyResponseError(get.StatusCode, res.ErrorType,
+			fmt.Errorf("Prometheus查询返回错误状态: %s, error: %s, Query: %s", res.Status, res.Error, query))
+	}
+
+	return res, nil
+}
+
 // PromLabelValues 获取 Prometheus label 的所有可用值
 // 用于前端生成下拉选择器
+// 响应携带基于值集合计算的 ETag 及值集合最近变化时间 Last-Modified，
+// 客户端通过 If-None-Match 或 If-Modified-Since 复用未变化的列表时返回 304
 func (datasourceController datasourceController) PromLabelValues(ctx *gin.Context) {
 	r := new(struct {
 		DatasourceId string `form:"datasourceId"`
 		LabelName    string `form:"labelName"`
 		MetricName   string `form:"metricName"` // 可选的 metric 名称，用于过滤
+		Refresh      bool   `form:"refresh"`    // 跳过服务端缓存，用户手动刷新时使用
 	})
 	BindQuery(ctx, r)
 
+	valueList, modifiedAt, err := getPromLabelValues(r.DatasourceId, r.LabelName, r.MetricName, r.Refresh)
+	if err != nil {
+		Service(ctx, func() (interface{}, interface{}) {
+			return nil, err
+		})
+		return
+	}
+
+	etag := buildLabelValuesETag(valueList)
+	ctx.Header("ETag", etag)
+	// no-cache 表示浏览器可以缓存，但每次使用前需携带 If-None-Match 重新验证
+	ctx.Header("Cache-Control", "no-cache")
+	lastModified := modifiedAt.UTC().Truncate(time.Second)
+	ctx.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+	if labelValuesNotModified(ctx.Request, etag, lastModified) {
+		ctx.Status(http.StatusNotModified)
+		return
+	}
+
 	Service(ctx, func() (interface{}, interface{}) {
-		if r.DatasourceId == "" || r.LabelName == "" {
-			return nil, fmt.Errorf("datasourceId 和 labelName 参数不能为空")
-		}
+		return valueList, nil
+	})
+}
 
-		source, err := ctx2.DO().DB.Datasource().Get(r.DatasourceId)
-		if err != nil {
-			return nil, fmt.Errorf("获取数据源失败: %w", err)
-		}
+// getPromLabelValues 获取 label 值列表及其最近变化时间，缓存有效时直接返回，refresh 为 true 时跳过缓存重新查询
+// 重新查询的值集合与缓存中的相同时沿用原变化时间，使 Last-Modified 只在值集合变化时更新
+func getPromLabelValues(datasourceId, labelName, metricName string, refresh bool) ([]string, time.Time, error) {
+	ttl := global.Config.PromLabelValuesCache.GetTTL()
+	key := labelName + "|" + metricName
 
-		// 构建查询：查询包含该 label 的所有时间序列
-		var query string
-		if r.MetricName != "" {
-			// 如果提供了 metric 名称，查询该 metric 的所有时间序列
-			query = fmt.Sprintf("%s{%s=~\".+\"}", r.MetricName, r.LabelName)
-		} else {
-			// 否则查询所有包含该 label 的时间序列（使用 up metric 作为基础）
-			query = fmt.Sprintf("up{%s=~\".+\"}", r.LabelName)
+	var previous *provider.LabelValuesEntry
+	if entry, ok := provider.GetLabelValuesCache(datasourceId, key); ok {
+		if !refresh && ttl > 0 && time.Now().Before(entry.ExpireAt) {
+			return entry.Values, entry.ModifiedAt, nil
 		}
+		previous = &entry
+	}
 
-		fullURL := fmt.Sprintf("%s/api/v1/query?query=%s&time=%d",
-			source.HTTP.URL, url.QueryEscape(query), time.Now().Unix())
+	valueList, err := queryPromLabelValues(datasourceId, labelName, metricName)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
 
-		get, err := tools.Get(tools.CreateBasicAuthHeader(source.Auth.User, source.Auth.Pass), fullURL, 10)
-		if err != nil {
-			return nil, fmt.Errorf("请求Prometheus失败: %w", err)
-		}
-		defer get.Body.Close()
+	modifiedAt := time.Now()
+	if previous != nil && slices.Equal(previous.Values, valueList) {
+		modifiedAt = previous.ModifiedAt
+	}
 
-		if get.StatusCode != 200 {
-			return nil, fmt.Errorf("Prometheus返回非200状态码: %d", get.StatusCode)
-		}
+	if ttl > 0 {
+		provider.SetLabelValuesCache(datasourceId, key, provider.LabelValuesEntry{Values: valueList, ModifiedAt: modifiedAt, ExpireAt: time.Now().Add(ttl)})
+	}
 
-		var res provider.QueryResponse
-		if err := tools.ParseReaderBody(get.Body, &res); err != nil {
-			return nil, fmt.Errorf("解析Prometheus响应失败: %w", err)
-		}
+	return valueList, modifiedAt, nil
+}
 
-		if res.Status != "success" {
-			return nil, fmt.Errorf("prometheus查询返回错误状态: %s", res.Status)
-		}
+// queryPromLabelValues 查询 Prometheus 中指定 label 的所有唯一值，返回排序后的列表
+func queryPromLabelValues(datasourceId, labelName, metricName string) ([]string, error) {
+	if datasourceId == "" || labelName == "" {
+		return nil, fmt.Errorf("datasourceId 和 labelName 参数不能为空")
+	}
+
+	source, err := ctx2.DO().DB.Datasource().Get(datasourceId)
+	if err != nil {
+		return nil, fmt.Errorf("获取数据源失败: %w", err)
+	}
+
+	// 构建查询：查询包含该 label 的所有时间序列
+	var query string
+	if metricName != "" {
+		// 如果提供了 metric 名称，查询该 metric 的所有时间序列
+		query = fmt.Sprintf("%s{%s=~\".+\"}", metricName, labelName)
+	} else {
+		// 否则查询所有包含该 label 的时间序列（使用 up metric 作为基础）
+		query = fmt.Sprintf("up{%s=~\".+\"}", labelName)
+	}
 
-		// 提取所有唯一的 label 值
-		values := make(map[string]bool)
-		for _, result := range res.VMData.VMResult {
-			if metricMap := result.Metric; metricMap != nil {
-				if value, exists := metricMap[r.LabelName]; exists {
-					if valueStr, ok := value.(string); ok && valueStr != "" {
-						values[valueStr] = true
-					}
+	fullURL := fmt.Sprintf("%s/api/v1/query?query=%s&time=%d",
+		source.HTTP.URL, url.QueryEscape(query), time.Now().Unix())
+
+	res, err := requestPromQuery(source, fullURL, query)
+	if err != nil {
+		return nil, err
+	}
+
+	// 提取所有唯一的 label 值
+	values := make(map[string]bool)
+	for _, result := range res.VMData.VMResult {
+		if metricMap := result.Metric; metricMap != nil {
+			if value, exists := metricMap[labelName]; exists {
+				if valueStr, ok := value.(string); ok && valueStr != "" {
+					values[valueStr] = true
 				}
 			}
 		}
+	}
 
-		// 转换为排序后的字符串数组
-		valueList := make([]string, 0, len(values))
-		for value := range values {
-			valueList = append(valueList, value)
-		}
+	// 转换为排序后的字符串数组
+	valueList := make([]string, 0, len(values))
+	for value := range values {
+		valueList = append(valueList, value)
+	}
 
-		// 简单排序
-		for i := 0; i < len(valueList)-1; i++ {
-			for j := i + 1; j < len(valueList); j++ {
-				if valueList[i] > valueList[j] {
-					valueList[i], valueList[j] = valueList[j], valueList[i]
-				}
-			}
+	sort.Strings(valueList)
+
+	return valueList, nil
+}
+
+// buildLabelValuesETag 根据排序后的 label 值集合计算强 ETag
+// 值集合不变时 ETag 保持稳定，与查询时间无关
+func buildLabelValuesETag(sortedValues []string) string {
+	return fmt.Sprintf("\"%s\"", tools.Md5Hash([]byte(strings.Join(sortedValues, "\n"))))
+}
+
+// labelValuesNotModified 判断条件请求是否可返回 304
+// 与 RFC 9110 一致：携带 If-None-Match 时只按 ETag 判断，否则按 If-Modified-Since 判断
+func labelValuesNotModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		return etagMatches(ifNoneMatch, etag)
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.After(since)
+}
+
+// etagMatches 判断 If-None-Match 请求头是否命中当前 ETag
+// 支持逗号分隔的多个 ETag、弱校验前缀 W/ 以及通配符 *
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.TrimPrefix(candidate, "W/") == etag {
+			return true
 		}
+	}
 
-		return valueList, nil
-	})
+	return false
 }
 
 func (datasourceController datasourceController) Ping(ctx *gin.Context) {
@@ -699,6 +893,17 @@ func (datasourceController datasourceController) SearchViewLogsContent(ctx *gin.
 		QueryStr := string(decodedBytes)
 
 		switch r.Type {
+		case provider.LokiDsProviderName:
+			client, err = provider.NewLokiClient(datasource)
+			if err != nil {
+				return nil, err
+			}
+
+			options = provider.LogQueryOptions{
+				Loki: provider.Loki{
+					Query: QueryStr,
+				},
+			}
 		case provider.VictoriaLogsDsProviderName:
 			client, err = provider.NewVictoriaLogsClient(ctx, datasource)
 			if err != nil {
@@ -734,6 +939,8 @@ func (datasourceController datasourceController) SearchViewLogsContent(ctx *gin.
 					Query: QueryStr,
 				},
 			}
+		default:
+			return nil, fmt.Errorf("不支持的日志数据源类型: %s", r.Type)
 		}
 
 		query, _, err := client.Query(options)
@@ -0,0 +1,556 @@
+package api
+
+import (
+	ctx2 "alertHub/internal/ctx"
+	"alertHub/internal/models"
+	"alertHub/internal/repo"
+	"alertHub/pkg/provider"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestEtagMatches(t *testing.T) {
+	etag := `"abc"`
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{"未携带请求头", "", false},
+		{"强校验命中", `"abc"`, true},
+		{"强校验不命中", `"def"`, false},
+		{"弱校验命中", `W/"abc"`, true},
+		{"弱校验不命中", `W/"def"`, false},
+		{"列表中命中", `"def", W/"abc"`, true},
+		{"列表中不命中", `"def", "ghi"`, false},
+		{"列表无空格", `"def","abc"`, true},
+		{"通配符", "*", true},
+		{"列表中的通配符", `"def", *`, true},
+		{"缺少引号不命中", "abc", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := etagMatches(tt.ifNoneMatch, etag); got != tt.want {
+				t.Fatalf("etagMatches(%q) got %v, want %v", tt.ifNoneMatch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPromLabelValuesNotModified(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	values := []string{"api", "node"}
+	provider.SetLabelValuesCache("ds-etag", "job|", provider.LabelValuesEntry{Values: values, ModifiedAt: time.Now(), ExpireAt: time.Now().Add(time.Minute)})
+	defer provider.RemoveLabelValuesCache("ds-etag")
+
+	etag := buildLabelValuesETag(values)
+
+	do := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/?datasourceId=ds-etag&labelName=job", nil)
+		if ifNoneMatch != "" {
+			c.Request.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		datasourceController{}.PromLabelValues(c)
+		c.Writer.WriteHeaderNow()
+		return w
+	}
+
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		wantStatus  int
+	}{
+		{"首次请求返回完整列表", "", http.StatusOK},
+		{"ETag 命中返回 304", etag, http.StatusNotModified},
+		{"弱校验 ETag 命中返回 304", "W/" + etag, http.StatusNotModified},
+		{"ETag 过期返回完整列表", `"stale"`, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := do(tt.ifNoneMatch)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status got %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("ETag"); got != etag {
+				t.Fatalf("ETag got %s, want %s", got, etag)
+			}
+			if tt.wantStatus == http.StatusNotModified && w.Body.Len() != 0 {
+				t.Fatalf("304 response must have empty body, got %q", w.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK && w.Body.Len() == 0 {
+				t.Fatal("200 response must carry the value list")
+			}
+		})
+	}
+}
+
+// fakeDatasourceDB 只实现数据源查询的 repo，其余方法未使用
+type fakeDatasourceDB struct {
+	repo.InterEntryRepo
+	repo.InterDatasourceRepo
+	sources map[string]models.AlertDataSource
+}
+
+func (f *fakeDatasourceDB) Datasource() repo.InterDatasourceRepo { return f }
+
+func (f *fakeDatasourceDB) Get(datasourceId string) (models.AlertDataSource, error) {
+	ds, ok := f.sources[datasourceId]
+	if !ok {
+		return ds, errors.New("datasource not found")
+	}
+	return ds, nil
+}
+
+// useFakeDatasources 将全局 DB 替换为只包含给定数据源的 fake
+func useFakeDatasources(t *testing.T, sources ...models.AlertDataSource) {
+	t.Helper()
+	db := &fakeDatasourceDB{sources: make(map[string]models.AlertDataSource)}
+	for _, ds := range sources {
+		db.sources[ds.ID] = ds
+	}
+	old := ctx2.DB
+	ctx2.DB = db
+	t.Cleanup(func() { ctx2.DB = old })
+}
+
+// newLabelValuesServer 模拟 Prometheus 查询接口，返回 job label 为当前 jobs 的序列
+func newLabelValuesServer(t *testing.T, jobs *atomic.Pointer[[]string]) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var result []string
+		for _, job := range *jobs.Load() {
+			result = append(result, fmt.Sprintf(`{"metric":{"job":%q},"value":[0,"1"]}`, job))
+		}
+		_, _ = fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[%s]}}`, strings.Join(result, ","))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestPromLabelValuesChangedValues(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var jobs atomic.Pointer[[]string]
+	jobs.Store(&[]string{"api", "node"})
+	srv := newLabelValuesServer(t, &jobs)
+
+	const dsId = "ds-changed"
+	useFakeDatasources(t, models.AlertDataSource{ID: dsId, HTTP: models.HTTP{URL: srv.URL}})
+	t.Cleanup(func() {
+		provider.RemoveLabelValuesCache(dsId)
+		provider.RemoveDatasourceHTTPClient(dsId)
+	})
+
+	do := func(query string, headers map[string]string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodGet, "/?datasourceId="+dsId+"&labelName=job"+query, nil)
+		for k, v := range headers {
+			c.Request.Header.Set(k, v)
+		}
+		datasourceController{}.PromLabelValues(c)
+		c.Writer.WriteHeaderNow()
+		return w
+	}
+
+	first := do("", nil)
+	etag, lastModified := first.Header().Get("ETag"), first.Header().Get("Last-Modified")
+	if first.Code != http.StatusOK || etag == "" || lastModified == "" {
+		t.Fatalf("first request got %d, ETag %q, Last-Modified %q", first.Code, etag, lastModified)
+	}
+
+	if w := do("", map[string]string{"If-None-Match": etag}); w.Code != http.StatusNotModified {
+		t.Fatalf("unchanged values with matching ETag got %d, want 304", w.Code)
+	}
+	if w := do("", map[string]string{"If-Modified-Since": lastModified}); w.Code != http.StatusNotModified {
+		t.Fatalf("unchanged values with If-Modified-Since got %d, want 304", w.Code)
+	}
+
+	// 值集合未变化时重新查询，Last-Modified 保持不变
+	time.Sleep(1100 * time.Millisecond)
+	same := do("&refresh=true", map[string]string{"If-None-Match": etag})
+	if same.Code != http.StatusNotModified || same.Header().Get("Last-Modified") != lastModified {
+		t.Fatalf("refreshed unchanged values got %d, Last-Modified %q, want 304 and %q",
+			same.Code, same.Header().Get("Last-Modified"), lastModified)
+	}
+
+	// label 值变化后，旧 ETag 不再命中，返回 200 与新的 ETag 和 Last-Modified
+	jobs.Store(&[]string{"api", "node", "redis"})
+	changed := do("&refresh=true", map[string]string{"If-None-Match": etag})
+	if changed.Code != http.StatusOK {
+		t.Fatalf("changed values got %d, want 200", changed.Code)
+	}
+	if got := changed.Header().Get("ETag"); got == "" || got == etag {
+		t.Fatalf("changed values must carry a different ETag, got %q (old %q)", got, etag)
+	}
+	if got := changed.Header().Get("Last-Modified"); got == lastModified {
+		t.Fatalf("changed values must advance Last-Modified, got %q", got)
+	}
+	if !strings.Contains(changed.Body.String(), "redis") {
+		t.Fatalf("changed values body %q must contain the new value", changed.Body.String())
+	}
+	if w := do("", map[string]string{"If-Modified-Since": lastModified}); w.Code != http.StatusOK {
+		t.Fatalf("If-Modified-Since before the change got %d, want 200", w.Code)
+	}
+}
+
+// seriesResponse 构造包含 n 条时间序列的查询响应，序列按 name-序号 标记来源
+func seriesResponse(name string, n int) provider.QueryResponse {
+	res := provider.QueryResponse{Status: "success"}
+	for i := 0; i < n; i++ {
+		res.VMData.VMResult = append(res.VMData.VMResult, provider.VMResult{
+			Metric: map[string]interface{}{"instance": fmt.Sprintf("%s-%d", name, i)},
+		})
+	}
+	return res
+}
+
+// instances 返回分页结果中每个数据源保留的序列标记
+func instances(ress []provider.QueryResponse) [][]string {
+	out := make([][]string, 0, len(ress))
+	for _, res := range ress {
+		ids := make([]string, 0, len(res.VMData.VMResult))
+		for _, r := range res.VMData.VMResult {
+			ids = append(ids, r.Metric["instance"].(string))
+		}
+		out = append(out, ids)
+	}
+	return out
+}
+
+func TestPaginateResponses(t *testing.T) {
+	errorRes := provider.QueryResponse{Status: "error", ErrorType: "timeout", Error: "query timed out"}
+
+	tests := []struct {
+		name      string
+		ress      []provider.QueryResponse
+		limit     int
+		offset    int
+		wantTotal int
+		// 每个数据源保留的序列数量及首条序列标记
+		wantCounts []int
+		wantFirst  []string
+	}{
+		{
+			name:       "跨数据源合并分页",
+			ress:       []provider.QueryResponse{seriesResponse("a", 30), seriesResponse("b", 40)},
+			limit:      25,
+			offset:     10,
+			wantTotal:  70,
+			wantCounts: []int{20, 5},
+			wantFirst:  []string{"a-10", "b-0"},
+		},
+		{
+			name:       "窗口只落在第二个数据源",
+			ress:       []provider.QueryResponse{seriesResponse("a", 30), seriesResponse("b", 40)},
+			limit:      10,
+			offset:     35,
+			wantTotal:  70,
+			wantCounts: []int{0, 10},
+			wantFirst:  []string{"", "b-5"},
+		},
+		{
+			name:       "最后一页不足 limit",
+			ress:       []provider.QueryResponse{seriesResponse("a", 30), seriesResponse("b", 40)},
+			limit:      25,
+			offset:     60,
+			wantTotal:  70,
+			wantCounts: []int{0, 10},
+			wantFirst:  []string{"", "b-30"},
+		},
+		{
+			name:       "offset 超出总数时停止",
+			ress:       []provider.QueryResponse{seriesResponse("a", 30), seriesResponse("b", 40)},
+			limit:      25,
+			offset:     70,
+			wantTotal:  70,
+			wantCounts: []int{0, 0},
+			wantFirst:  []string{"", ""},
+		},
+		{
+			name:       "未设置分页返回全部",
+			ress:       []provider.QueryResponse{seriesResponse("a", 3), seriesResponse("b", 4)},
+			wantTotal:  7,
+			wantCounts: []int{3, 4},
+			wantFirst:  []string{"a-0", "b-0"},
+		},
+		{
+			name:       "中间数据源返回错误时窗口跨过该数据源",
+			ress:       []provider.QueryResponse{seriesResponse("a", 5), errorRes, seriesResponse("c", 5)},
+			limit:      4,
+			offset:     3,
+			wantTotal:  10,
+			wantCounts: []int{2, 0, 2},
+			wantFirst:  []string{"a-3", "", "c-0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paginated, total := paginateResponses(tt.ress, tt.limit, tt.offset)
+			if total != tt.wantTotal {
+				t.Fatalf("total got %d, want %d", total, tt.wantTotal)
+			}
+			if len(paginated) != len(tt.ress) {
+				t.Fatalf("got %d responses, want %d", len(paginated), len(tt.ress))
+			}
+
+			returned := 0
+			for i, ids := range instances(paginated) {
+				if len(ids) != tt.wantCounts[i] {
+					t.Fatalf("response %d got %d series, want %d", i, len(ids), tt.wantCounts[i])
+				}
+				first := ""
+				if len(ids) > 0 {
+					first = ids[0]
+				}
+				if first != tt.wantFirst[i] {
+					t.Fatalf("response %d first series got %q, want %q", i, first, tt.wantFirst[i])
+				}
+				if paginated[i].Status != tt.ress[i].Status || paginated[i].Error != tt.ress[i].Error {
+					t.Fatalf("response %d lost its status or error", i)
+				}
+				returned += len(ids)
+			}
+			if tt.limit > 0 && returned > tt.limit {
+				t.Fatalf("returned %d series, exceeds limit %d", returned, tt.limit)
+			}
+		})
+	}
+}
+
+func TestRequestPromQueryClassifiesFailures(t *testing.T) {
+	respond := func(status int, body string, delay time.Duration) *httptest.Server {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if delay > 0 {
+				select {
+				case <-time.After(delay):
+				case <-r.Context().Done():
+					return
+				}
+			}
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+		}))
+		t.Cleanup(srv.Close)
+		return srv
+	}
+
+	refused := httptest.NewServer(http.NotFoundHandler())
+	refused.Close()
+	plain := respond(http.StatusOK, `{"status":"success"}`, 0)
+
+	tests := []struct {
+		name       string
+		url        string
+		timeout    int64
+		want       provider.QueryErrorCategory
+		wantStatus int
+	}{
+		{"DNS 解析失败", "http://alerthub-test.invalid", 0, provider.QueryErrorConnection, 0},
+		{"连接被拒绝", refused.URL, 0, provider.QueryErrorConnection, 0},
+		{"查询超时", respond(http.StatusOK, `{"status":"success"}`, 3*time.Second).URL, 1, provider.QueryErrorTimeout, 0},
+		{"以 https 访问 http 服务", strings.Replace(plain.URL, "http://", "https://", 1), 0, provider.QueryErrorTLS, 0},
+		{"语句错误", respond(http.StatusBadRequest, `{"status":"error","errorType":"bad_data","error":"parse error"}`, 0).URL, 0, provider.QueryErrorSyntax, http.StatusBadRequest},
+		{"认证失败", respond(http.StatusUnauthorized, `Unauthorized`, 0).URL, 0, provider.QueryErrorAuth, http.StatusUnauthorized},
+		{"服务端错误", respond(http.StatusInternalServerError, `{"status":"error","errorType":"internal","error":"boom"}`, 0).URL, 0, provider.QueryErrorServer, http.StatusInternalServerError},
+		{"后端不可用", respond(http.StatusServiceUnavailable, `{"status":"error","errorType":"unavailable","error":"down"}`, 0).URL, 0, provider.QueryErrorConnection, http.StatusServiceUnavailable},
+		{"响应体格式错误", respond(http.StatusOK, `<html>not json</html>`, 0).URL, 0, provider.QueryErrorServer, http.StatusOK},
+		{"200 但执行超时", respond(http.StatusOK, `{"status":"error","errorType":"timeout","error":"query timed out"}`, 0).URL, 0, provider.QueryErrorTimeout, http.StatusOK},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := models.AlertDataSource{
+				ID:   fmt.Sprintf("classify-%d", i),
+				HTTP: models.HTTP{URL: tt.url, QueryTimeout: tt.timeout},
+			}
+			defer provider.RemoveDatasourceHTTPClient(source.ID)
+
+			_, err := requestPromQuery(source, tt.url+"/api/v1/query?query=up", "up")
+			if err == nil {
+				t.Fatal("want error")
+			}
+
+			var qe *provider.QueryError
+			if !errors.As(err, &qe) {
+				t.Fatalf("got unclassified error %v", err)
+			}
+			if qe.Category != tt.want {
+				t.Fatalf("category got %s, want %s (err: %v)", qe.Category, tt.want, err)
+			}
+			if qe.StatusCode != tt.wantStatus {
+				t.Fatalf("status got %d, want %d", qe.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRequestPromQueryReusesConnectionsUnderConcurrency(t *testing.T) {
+	const (
+		concurrency = 100
+		// 与 provider 中每个数据源保留的空闲连接数一致
+		maxIdlePerHost = 32
+	)
+
+	var opened, inFlight, peak, unauthorized atomic.Int64
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+			unauthorized.Add(1)
+		}
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+		inFlight.Add(-1)
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"vector","result":[]}}`))
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			opened.Add(1)
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	source := models.AlertDataSource{
+		ID:   "pool-concurrent",
+		HTTP: models.HTTP{URL: srv.URL},
+		Auth: models.Auth{User: "admin", Pass: "secret"},
+	}
+	defer provider.RemoveDatasourceHTTPClient(source.ID)
+
+	// 所有 goroutine 就绪后同时发起查询
+	burst := func() {
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				<-start
+				if _, err := requestPromQuery(source, srv.URL+"/api/v1/query?query=up", "up"); err != nil {
+					t.Error(err)
+				}
+			}()
+		}
+		close(start)
+		wg.Wait()
+		// 等待连接放回连接池
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	burst()
+	first := opened.Load()
+	if p := peak.Load(); p < concurrency/2 {
+		t.Fatalf("peak in-flight queries %d, want the burst to run concurrently", p)
+	}
+	if first > concurrency {
+		t.Fatalf("first burst opened %d connections, want at most %d", first, concurrency)
+	}
+
+	// 第二轮并发查询复用连接池中保留的空闲连接
+	burst()
+	if second := opened.Load() - first; second > concurrency-maxIdlePerHost {
+		t.Fatalf("second burst opened %d new connections, want at most %d (idle connections reused)", second, concurrency-maxIdlePerHost)
+	}
+
+	// 串行查询全部复用已有连接
+	before := opened.Load()
+	for i := 0; i < 20; i++ {
+		if _, err := requestPromQuery(source, srv.URL+"/api/v1/query?query=up", "up"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := opened.Load() - before; n != 0 {
+		t.Fatalf("sequential queries opened %d new connections, want 0", n)
+	}
+
+	if n := unauthorized.Load(); n != 0 {
+		t.Fatalf("%d requests missed the basic auth header", n)
+	}
+}
+
+func TestInjectLabelFilters(t *testing.T) {
+	instances := map[string][]string{"instance": {"h1", "h2"}}
+
+	tests := []struct {
+		name     string
+		query    string
+		matchers map[string][]string
+		want     string
+	}{
+		{"裸指标", "up", instances, `up{instance=~"h1|h2"}`},
+		{"空选择器", "node_load1{}", instances, `node_load1{instance=~"h1|h2"}`},
+		{"只含空白的选择器", "node_load1{ }", instances, `node_load1{instance=~"h1|h2"}`},
+		{"已有标签选择器", `up{job="node"}`, instances, `up{job="node", instance=~"h1|h2"}`},
+		{"选择器取值含右大括号", `up{path="/a}"}`, instances, `up{path="/a}", instance=~"h1|h2"}`},
+		{"函数调用", "rate(http_requests_total[5m])", instances, `rate(http_requests_total{instance=~"h1|h2"}[5m])`},
+		{"范围选择器", "http_requests_total[5m]", instances, `http_requests_total{instance=~"h1|h2"}[5m]`},
+		{"已有选择器的范围选择器", `node_cpu_seconds_total{mode="idle"}[5m]`, instances, `node_cpu_seconds_total{mode="idle", instance=~"h1|h2"}[5m]`},
+		{"子查询", "rate(http_requests_total[5m:1m])", instances, `rate(http_requests_total{instance=~"h1|h2"}[5m:1m])`},
+		{"offset", "up offset 5m", instances, `up{instance=~"h1|h2"} offset 5m`},
+		{"聚合分组", "sum by (job) (rate(http_requests_total[5m]))", instances, `sum by (job) (rate(http_requests_total{instance=~"h1|h2"}[5m]))`},
+		{"嵌套函数与数字参数", "histogram_quantile(0.99, sum by (le) (rate(req_bucket[5m])))", instances, `histogram_quantile(0.99, sum by (le) (rate(req_bucket{instance=~"h1|h2"}[5m])))`},
+		{"跳过字符串参数", `label_replace(up, "dst", "$1", "src", "(.*)")`, instances, `label_replace(up{instance=~"h1|h2"}, "dst", "$1", "src", "(.*)")`},
+		{"无指标名称时不修改", "time()", instances, "time()"},
+		{"无过滤条件时不修改", "up", map[string][]string{"instance": nil, "": {"x"}}, "up"},
+		{"多个标签按名称排序", "up", map[string][]string{"job": {"node"}, "instance": {"h1"}}, `up{instance=~"h1", job=~"node"}`},
+		// 取值按字面量匹配：正则元字符被转义，PromQL 字符串中的反斜杠与引号再次转义
+		{"取值中的正则元字符", "up", map[string][]string{"instance": {"10.0.0.1:9100", "a|b"}}, `up{instance=~"10\\.0\\.0\\.1:9100|a\\|b"}`},
+		{"取值中的引号与反斜杠", "up", map[string][]string{"instance": {`a"b\c`}}, `up{instance=~"a\"b\\\\c"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := injectLabelFilters(tt.query, tt.matchers); got != tt.want {
+				t.Fatalf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindMetricNameEnd(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  int
+	}{
+		{"裸指标", "up", 2},
+		{"带冒号的录制规则", "job:http_requests:rate5m", 24},
+		{"函数调用", "rate(http_requests_total[5m])", 24},
+		{"时间单位不是指标", "rate(x[5m])", 6},
+		{"聚合关键字", "sum without (instance) (node_load1)", 34},
+		{"科学计数法", "1e3", -1},
+		{"字符串中的标识符", `label_join(up, "foo", ",", "a")`, 13},
+		{"只有函数", "time()", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findMetricNameEnd(tt.query); got != tt.want {
+				t.Fatalf("findMetricNameEnd(%q) got %d, want %d", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,6 +2,8 @@ package config
 
 import (
 	"log"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -12,6 +14,13 @@ type App struct {
 	Redis  Redis  `json:"Redis"`
 	Jwt    Jwt    `json:"Jwt"`
 	Jaeger Jaeger `json:"Jaeger"`
+
+	ExporterPlatformReport ExporterPlatformReport `json:"ExporterPlatformReport"`
+	NoticeRateLimit        NoticeRateLimit        `json:"NoticeRateLimit"`
+
+	ProcessOperationLogRetention ProcessOperationLogRetention `json:"ProcessOperationLogRetention"`
+
+	PromLabelValuesCache PromLabelValuesCache `json:"PromLabelValuesCache"`
 }
 
 type Server struct {
@@ -44,6 +53,86 @@ type Jaeger struct {
 	URL string `json:"url"`
 }
 
+// ExporterPlatformReport 跨租户 Exporter 巡检汇总报告配置
+// 面向平台运维，汇总所有租户的巡检结果后推送到指定租户下的通知组
+type ExporterPlatformReport struct {
+	Enabled        bool     `json:"enabled"`
+	TenantId       string   `json:"tenantId"`       // 通知组所属租户
+	NoticeGroups   []string `json:"noticeGroups"`   // 运维通知组ID列表
+	CronExpression string   `json:"cronExpression"` // 5 段式 Cron 表达式，如 "30 9 * * *"
+	TopTenants     int      `json:"topTenants"`     // 租户排行展示数量，默认 10
+}
+
+// defaultNoticeRateLimitMaxWait 通知限速默认最长排队时间（秒）
+const defaultNoticeRateLimitMaxWait = 30
+
+// NoticeRateLimit 通知渠道发送限流配置
+// 按机器人/Webhook 维度限速，避免告警风暴时触发平台限流导致通知丢失，超出限速的消息排队等待发送
+type NoticeRateLimit struct {
+	Enabled *bool          `json:"enabled"` // 默认开启
+	MaxWait int            `json:"maxWait"` // 单条消息最长排队时间（秒），默认 30，超过后放弃发送并返回错误
+	Limits  map[string]int `json:"limits"`  // 各通知类型每分钟发送上限，0 表示不限流，未配置时使用内置默认值
+}
+
+// IsEnabled 是否开启限流，未配置时默认开启
+func (n NoticeRateLimit) IsEnabled() bool {
+	return n.Enabled == nil || *n.Enabled
+}
+
+// GetMaxWait 获取单条消息最长排队时间
+func (n NoticeRateLimit) GetMaxWait() time.Duration {
+	if n.MaxWait <= 0 {
+		return defaultNoticeRateLimitMaxWait * time.Second
+	}
+	return time.Duration(n.MaxWait) * time.Second
+}
+
+// defaultOperationLogRetentionDays 操作日志明细默认保留天数
+const defaultOperationLogRetentionDays = 30
+
+// ProcessOperationLogRetention 处理操作日志保留与汇总配置
+// 超过保留期的明细日志按 流程/日期/操作类型 汇总后删除
+type ProcessOperationLogRetention struct {
+	Enabled             bool           `json:"enabled"`
+	CronExpression      string         `json:"cronExpression"`      // 5 段式 Cron 表达式，默认 "30 3 * * *"
+	RetentionDays       int            `json:"retentionDays"`       // 明细保留天数，默认 30
+	TenantRetentionDays map[string]int `json:"tenantRetentionDays"` // 按租户覆盖保留天数
+}
+
+// GetRetentionDays 获取租户的明细保留天数，租户未单独配置时使用全局配置
+// viper 会将 map 的 key 转为小写，这里统一按小写匹配
+func (r ProcessOperationLogRetention) GetRetentionDays(tenantId string) int {
+	for k, v := range r.TenantRetentionDays {
+		if strings.EqualFold(k, tenantId) && v > 0 {
+			return v
+		}
+	}
+
+	if r.RetentionDays > 0 {
+		return r.RetentionDays
+	}
+	return defaultOperationLogRetentionDays
+}
+
+// defaultPromLabelValuesCacheTTL Prometheus label 值缓存默认有效期（秒）
+const defaultPromLabelValuesCacheTTL = 60
+
+// PromLabelValuesCache Prometheus label 值下拉列表缓存配置
+type PromLabelValuesCache struct {
+	TTL int `json:"ttl"` // 缓存有效期（秒），默认 60，小于 0 表示不缓存
+}
+
+// GetTTL 获取缓存有效期
+func (c PromLabelValuesCache) GetTTL() time.Duration {
+	if c.TTL == 0 {
+		return defaultPromLabelValuesCacheTTL * time.Second
+	}
+	if c.TTL < 0 {
+		return 0
+	}
+	return time.Duration(c.TTL) * time.Second
+}
+
 var (
 	configFile = "config/config.yaml"
 )
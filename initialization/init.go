@@ -49,9 +49,15 @@ func InitBasic() {
 	// 导入数据源 Client 到存储池
 	importClientPools(ctx)
 
+	// 监听 Consul 服务目录，变更时自动同步目标
+	watchConsulTargets(ctx)
+
 	// 定时任务，清理历史通知记录和历史拨测数据
 	go gcHistoryData(ctx)
 
+	// 定时任务，汇总并清理超过保留期的处理操作日志
+	go rollupProcessOperationLog(ctx)
+
 	// 定时任务，每年12月1日自动生成次年值班表
 	go autoGenerateNextYearDutySchedule(ctx)
 
@@ -113,6 +119,19 @@ func importClientPools(ctx *ctx.Context) {
 	}
 }
 
+// watchConsulTargets 为所有 Consul 数据源开启服务目录监听
+func watchConsulTargets(ctx *ctx.Context) {
+	list, err := ctx.DB.Datasource().List("", "", "consul", "")
+	if err != nil {
+		logc.Error(ctx.Ctx, err.Error())
+		return
+	}
+
+	for _, datasource := range list {
+		services.ConsulService.WatchTargets(datasource)
+	}
+}
+
 func gcHistoryData(ctx *ctx.Context) {
 	// gc probe history data and notice history record
 	tools.NewCronjob("00 00 */1 * *", func() {
@@ -128,6 +147,26 @@ func gcHistoryData(ctx *ctx.Context) {
 	})
 }
 
+// rollupProcessOperationLog 处理操作日志汇总任务
+// 默认每天凌晨03:30将超过保留期的明细日志按天汇总后删除
+func rollupProcessOperationLog(ctx *ctx.Context) {
+	cfg := global.Config.ProcessOperationLogRetention
+	if !cfg.Enabled {
+		return
+	}
+
+	spec := cfg.CronExpression
+	if spec == "" {
+		spec = "30 3 * * *"
+	}
+
+	tools.NewCronjob(spec, func() {
+		if err := services.ProcessTraceService.RollupOperationLogs(cfg); err != nil {
+			logc.Errorf(ctx.Ctx, "处理操作日志汇总失败: %s", err.Error())
+		}
+	})
+}
+
 // autoGenerateNextYearDutySchedule 自动生成次年值班表
 // 定时任务：每年12月1日凌晨00:00触发
 func autoGenerateNextYearDutySchedule(ctx *ctx.Context) {
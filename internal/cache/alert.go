@@ -3,7 +3,9 @@ package cache
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 	"alertHub/internal/models"
 	"alertHub/pkg/tools"
 
@@ -12,6 +14,9 @@ import (
 	"github.com/zeromicro/go-zero/core/logc"
 )
 
+// alertFingerprintIndexTTL 指纹索引的过期时间，活跃告警每次推送都会续期
+const alertFingerprintIndexTTL = 24 * time.Hour
+
 type (
 	// AlertCache 用于管理告警事件缓存操作
 	AlertCache struct {
@@ -26,6 +31,7 @@ type (
 		GetFingerprintsByRuleId(tenantId, faultCenterId, ruleId string) []string
 		GetAllEvents(key models.AlertEventCacheKey) (map[string]*models.AlertCurEvent, error)
 		GetEventFromCache(tenantId, faultCenterId, fingerprint string) (models.AlertCurEvent, error)
+		GetFingerprintIndex(tenantId, fingerprint string) ([]models.AlertFingerprintIndex, error)
 	}
 )
 
@@ -40,12 +46,19 @@ func newAlertCacheInterface(r *redis.Client) AlertCacheInterface {
 func (a *AlertCache) PushAlertEvent(event *models.AlertCurEvent) {
 	key := models.BuildAlertEventCacheKey(event.TenantId, event.FaultCenterId)
 	a.setEventCacheHash(key, event.Fingerprint, tools.JsonMarshalToString(event))
+
+	// 维护指纹索引，按指纹查找事件时无需遍历所有故障中心
+	// 每次推送都会刷新过期时间，未经 RemoveAlertEvent 清理的索引会自动过期
+	indexKey := string(models.BuildAlertFingerprintIndexKey(event.TenantId, event.Fingerprint))
+	a.rc.HSet(indexKey, event.FaultCenterId, event.EventId)
+	a.rc.Expire(indexKey, alertFingerprintIndexTTL)
 }
 
 // RemoveAlertEvent 从故障中心的缓存中移除事件
 func (a *AlertCache) RemoveAlertEvent(tenantId, faultCenterId, fingerprint string) {
 	key := models.BuildAlertEventCacheKey(tenantId, faultCenterId)
 	a.deleteEventCacheHash(key, fingerprint)
+	a.rc.HDel(string(models.BuildAlertFingerprintIndexKey(tenantId, fingerprint)), faultCenterId)
 }
 
 // GetAllEvents 获取故障中心的所有事件
@@ -105,6 +118,29 @@ func (a *AlertCache) GetEventFromCache(tenantId, faultCenterId, fingerprint stri
 	return event, nil
 }
 
+// GetFingerprintIndex 通过指纹索引获取事件所在的故障中心和事件ID，按故障中心ID排序
+// 只返回与故障中心事件缓存一致的索引，不一致的索引视为失效并删除
+func (a *AlertCache) GetFingerprintIndex(tenantId, fingerprint string) ([]models.AlertFingerprintIndex, error) {
+	indexKey := string(models.BuildAlertFingerprintIndexKey(tenantId, fingerprint))
+	entries, err := a.rc.HGetAll(indexKey).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	var indexes []models.AlertFingerprintIndex
+	for faultCenterId, eventId := range entries {
+		event, err := a.GetEventFromCache(tenantId, faultCenterId, fingerprint)
+		if err != nil || event.EventId != eventId {
+			a.rc.HDel(indexKey, faultCenterId)
+			continue
+		}
+		indexes = append(indexes, models.AlertFingerprintIndex{FaultCenterId: faultCenterId, EventId: eventId})
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].FaultCenterId < indexes[j].FaultCenterId })
+
+	return indexes, nil
+}
+
 // 封装 Redis 操作
 func (a *AlertCache) setEventCacheHash(key models.AlertEventCacheKey, field, value string) {
 	a.rc.HSet(string(key), field, value)
@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	consulclient "alertHub/pkg/consul"
+)
 
 // ConsulTarget 从 Consul 发现的目标机器追踪表
 type ConsulTarget struct {
@@ -11,7 +15,9 @@ type ConsulTarget struct {
 	Labels             map[string]interface{} `gorm:"serializer:json" json:"labels"`                        // 标签信息 (Prometheus Labels)
 	ServiceID          string                 `gorm:"uniqueIndex:idx_tenant_service_id;type:varchar(255)" json:"serviceId"` // Consul ServiceID (租户内唯一标识，指定长度避免索引错误)
 	ServiceName        string                 `gorm:"type:varchar(255)" json:"serviceName"`               // Consul Service Name
+	DatasourceId       string                 `gorm:"index;type:varchar(128)" json:"datasourceId"`          // 所属 Consul 数据源ID，为空表示租户的默认 Consul 数据源
 	Status             string                 `gorm:"type:varchar(64)" json:"status"`                      // 状态: "passing" (正常) / "warning" (警告) / "critical" (严重) / "no checks" (无检查)
+	HealthCheck        *consulclient.HealthCheckConfig `gorm:"serializer:json" json:"healthCheck"`                 // 注册时附带的健康检查配置，重新上线时按原配置注册
 	ConsulDeregistered bool                   `gorm:"column:consul_deregistered" json:"consulDeregistered"` // 是否已从 Consul 中删除
 	DeregistrationTime *time.Time             `json:"deregistrationTime"`                                   // 注销时间戳
 	CreatedAt          time.Time              `json:"createdAt"`
@@ -1,5 +1,10 @@
 package models
 
+import (
+	"fmt"
+	"time"
+)
+
 type AlertDataSource struct {
 	TenantId         string                 `json:"tenantId"`
 	ID               string                 `json:"id"`
@@ -21,8 +26,35 @@ type AlertDataSource struct {
 }
 
 type HTTP struct {
-	URL     string `json:"url"`
-	Timeout int64  `json:"timeout"`
+	URL          string `json:"url"`
+	Timeout      int64  `json:"timeout"`
+	QueryTimeout int64  `json:"queryTimeout"` // 指标查询超时时间（秒），范围 1-120，未配置时默认 10
+}
+
+// 数据源指标查询超时时间（秒）
+const (
+	DefaultQueryTimeout int64 = 10
+	MinQueryTimeout     int64 = 1
+	MaxQueryTimeout     int64 = 120
+)
+
+// GetQueryTimeout 获取指标查询超时时间，未配置时使用默认值
+func (h HTTP) GetQueryTimeout() time.Duration {
+	if h.QueryTimeout <= 0 {
+		return time.Duration(DefaultQueryTimeout) * time.Second
+	}
+	return time.Duration(h.QueryTimeout) * time.Second
+}
+
+// ValidateQueryTimeout 校验指标查询超时时间，0 表示使用默认值
+func (h HTTP) ValidateQueryTimeout() error {
+	if h.QueryTimeout == 0 {
+		return nil
+	}
+	if h.QueryTimeout < MinQueryTimeout || h.QueryTimeout > MaxQueryTimeout {
+		return fmt.Errorf("查询超时时间必须在 %d-%d 秒之间", MinQueryTimeout, MaxQueryTimeout)
+	}
+	return nil
 }
 
 type Auth struct {
@@ -50,11 +82,35 @@ type AWSCloudWatch struct {
 
 // DsConsulConfig Consul 数据源配置
 type DsConsulConfig struct {
-	Address      string `json:"address"`       // Consul 服务器地址（完整 URL，例：http://10.10.218.45:8500）
-	Host         string `json:"host"`          // Consul 主机地址（兼容旧格式，如果没有 Address 则自动组合）
-	Port         int    `json:"port"`          // Consul 端口（兼容旧格式，如果没有 Address 则自动组合）
-	Token        string `json:"token"`         // Consul 认证令牌（可选）
-	SyncInterval int    `json:"syncInterval"`  // 同步间隔（秒），范围 10-3600，默认 60
+	Address      string `json:"address"`      // Consul 服务器地址（完整 URL，例：http://10.10.218.45:8500）
+	Host         string `json:"host"`         // Consul 主机地址（兼容旧格式，如果没有 Address 则自动组合）
+	Port         int    `json:"port"`         // Consul 端口（兼容旧格式，如果没有 Address 则自动组合）
+	Token        string `json:"token"`        // Consul 认证令牌（可选）
+	SyncInterval int    `json:"syncInterval"` // 同步间隔（秒），范围 10-3600，默认 60
+	EnableWatch  bool   `json:"enableWatch"`  // 开启服务目录变更自动同步，默认关闭
+	SyncScope    string `json:"syncScope"`    // 同步范围：agent（默认，仅同步所连接 Agent 上注册的服务）或 catalog（集群内所有节点的服务）
+}
+
+const (
+	// ConsulSyncScopeAgent 只同步所连接 Agent 上注册的服务，与注册目标时写入的范围一致
+	ConsulSyncScopeAgent = "agent"
+	// ConsulSyncScopeCatalog 同步集群服务目录中所有节点的服务
+	ConsulSyncScopeCatalog = "catalog"
+)
+
+// ValidateSyncScope 校验同步范围，空值表示使用默认的 agent 范围
+func (c DsConsulConfig) ValidateSyncScope() error {
+	switch c.SyncScope {
+	case "", ConsulSyncScopeAgent, ConsulSyncScopeCatalog:
+		return nil
+	default:
+		return fmt.Errorf("不支持的 Consul 同步范围: %s，可选值为 %s 或 %s", c.SyncScope, ConsulSyncScopeAgent, ConsulSyncScopeCatalog)
+	}
+}
+
+// UseCatalogScope 是否按集群服务目录同步，未配置时按 Agent 范围同步
+func (c DsConsulConfig) UseCatalogScope() bool {
+	return c.SyncScope == ConsulSyncScopeCatalog
 }
 
 func (d *AlertDataSource) GetEnabled() *bool {
@@ -64,3 +120,14 @@ func (d *AlertDataSource) GetEnabled() *bool {
 	}
 	return d.Enabled
 }
+
+// DatasourceHealthResult 单个数据源的健康检查结果
+type DatasourceHealthResult struct {
+	DatasourceId string `json:"datasourceId"`
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Reachable    bool   `json:"reachable"`
+	LatencyMs    int64  `json:"latencyMs"`
+	Error        string `json:"error,omitempty"`
+	CheckedAt    int64  `json:"checkedAt"`
+}
@@ -6,11 +6,13 @@ import "time"
 type ExporterMonitorConfig struct {
 	ID               int64     `gorm:"column:id;primary_key;AUTO_INCREMENT" json:"id"`
 	TenantId         string    `gorm:"column:tenant_id;type:varchar(64);not null;uniqueIndex:uk_tenant" json:"tenantId"`
-	Enabled          *bool     `gorm:"column:enabled;type:tinyint(1);default:1" json:"enabled"`              // 是否启用巡检
-	DatasourceIds    []string  `gorm:"column:datasource_ids;serializer:json" json:"datasourceIds"`           // 监控的数据源ID列表
-	InspectionTimes  []string  `gorm:"column:inspection_times;serializer:json" json:"inspectionTimes"`       // 巡检时间配置 (如: ["09:00", "21:00"])
-	HistoryRetention int       `gorm:"column:history_retention;type:int;default:90" json:"historyRetention"` // 历史保留天数,默认90天
-	AutoRefresh      *bool     `gorm:"column:auto_refresh;type:tinyint(1);default:0" json:"autoRefresh"`     // 前端自动刷新开关
+	Enabled          *bool     `gorm:"column:enabled;type:tinyint(1);default:1" json:"enabled"`                // 是否启用巡检
+	DatasourceIds    []string  `gorm:"column:datasource_ids;serializer:json" json:"datasourceIds"`             // 监控的数据源ID列表
+	InspectionTimes  []string  `gorm:"column:inspection_times;serializer:json" json:"inspectionTimes"`         // 巡检时间配置 (如: ["09:00", "21:00"])
+	HistoryRetention int       `gorm:"column:history_retention;type:int;default:90" json:"historyRetention"`   // 历史保留天数,默认90天
+	AutoRefresh      *bool     `gorm:"column:auto_refresh;type:tinyint(1);default:0" json:"autoRefresh"`       // 前端自动刷新开关
+	GoodAvailability float64   `gorm:"column:good_availability;type:double;default:0" json:"goodAvailability"` // 可用率良好阈值(%),0 表示未配置,按默认95
+	WarnAvailability float64   `gorm:"column:warn_availability;type:double;default:0" json:"warnAvailability"` // 可用率告警阈值(%),0 表示未配置,按默认80
 	CreatedAt        time.Time `gorm:"column:created_at;type:datetime;default:CURRENT_TIMESTAMP" json:"createdAt"`
 	UpdatedAt        time.Time `gorm:"column:updated_at;type:datetime;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP" json:"updatedAt"`
 }
@@ -36,6 +38,32 @@ func (c *ExporterMonitorConfig) GetAutoRefresh() bool {
 	return *c.AutoRefresh
 }
 
+// 可用率阈值默认值
+const (
+	DefaultGoodAvailability = 95.0
+	DefaultWarnAvailability = 80.0
+)
+
+// HasAvailabilityThresholds 是否显式配置了可用率阈值
+func (c *ExporterMonitorConfig) HasAvailabilityThresholds() bool {
+	return c.GoodAvailability > 0 || c.WarnAvailability > 0
+}
+
+// GetAvailabilityThresholds 获取可用率阈值,未配置或配置不合法时使用默认值 95/80
+func (c *ExporterMonitorConfig) GetAvailabilityThresholds() (good, warn float64) {
+	good, warn = c.GoodAvailability, c.WarnAvailability
+	if good <= 0 || good > 100 {
+		good = DefaultGoodAvailability
+	}
+	if warn <= 0 || warn > good {
+		warn = DefaultWarnAvailability
+	}
+	if warn > good {
+		warn = good
+	}
+	return good, warn
+}
+
 // ExporterReportSchedule Exporter 报告推送配置表
 type ExporterReportSchedule struct {
 	ID             int64     `gorm:"column:id;primary_key;AUTO_INCREMENT" json:"id"`
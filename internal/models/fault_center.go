@@ -98,6 +98,20 @@ func BuildAlertEventCacheKey(tenantId, faultCenterId string) AlertEventCacheKey
 	return AlertEventCacheKey(fmt.Sprintf("w8t:%s:%s:%s.events", tenantId, FaultCenterPrefix, faultCenterId))
 }
 
+// AlertFingerprintIndexKey 指纹索引，每个指纹一个 hash，field 为故障中心ID，value 为事件ID
+// 同一指纹在多个故障中心存在时各自独立记录，互不覆盖
+type AlertFingerprintIndexKey string
+
+func BuildAlertFingerprintIndexKey(tenantId, fingerprint string) AlertFingerprintIndexKey {
+	return AlertFingerprintIndexKey(fmt.Sprintf("w8t:%s:%s:fingerprint.index:%s", tenantId, FaultCenterPrefix, fingerprint))
+}
+
+// AlertFingerprintIndex 指纹所对应的故障中心和事件ID
+type AlertFingerprintIndex struct {
+	FaultCenterId string `json:"faultCenterId"`
+	EventId       string `json:"eventId"`
+}
+
 type AlertMuteCacheKey string
 
 func BuildAlertMuteCacheKey(tenantId, faultCenterId string) AlertMuteCacheKey {
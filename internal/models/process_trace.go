@@ -84,6 +84,29 @@ type ProcessOperationLog struct {
 	UserAgent     string                 `json:"userAgent"`                                    // 用户代理
 }
 
+// RollupDateLayout 汇总日期格式
+const RollupDateLayout = "2006-01-02"
+
+// RollupDate 返回时间戳所在的汇总日期
+// 统一按 UTC 自然日划分，避免不同时区的服务器把同一时刻汇总到不同日期
+func RollupDate(ts int64) string {
+	return time.Unix(ts, 0).UTC().Format(RollupDateLayout)
+}
+
+// ProcessOperationLogRollup 处理操作日志按天汇总
+// 超过保留期的明细日志会汇总到该表后删除，用于长周期统计
+type ProcessOperationLogRollup struct {
+	ID            uint   `json:"id" gorm:"primaryKey;autoIncrement"`
+	TenantId      string `json:"tenantId" gorm:"uniqueIndex:idx_op_rollup;type:varchar(64)"`
+	ProcessId     string `json:"processId" gorm:"uniqueIndex:idx_op_rollup;type:varchar(64)"`
+	Date          string `json:"date" gorm:"uniqueIndex:idx_op_rollup;type:varchar(16)"` // 汇总日期（UTC），格式 2006-01-02
+	OperationType string `json:"operationType" gorm:"uniqueIndex:idx_op_rollup;type:varchar(64)"`
+	EventId       string `json:"eventId"`
+	Count         int64  `json:"count"`     // 当天该类型的操作次数
+	FirstTime     int64  `json:"firstTime"` // 当天首次操作时间
+	LastTime      int64  `json:"lastTime"`  // 当天最后操作时间
+}
+
 // TableName 指定表名
 func (pt *ProcessTrace) TableName() string {
 	return "process_trace"
@@ -93,6 +116,10 @@ func (pol *ProcessOperationLog) TableName() string {
 	return "process_operation_log"
 }
 
+func (r *ProcessOperationLogRollup) TableName() string {
+	return "process_operation_log_rollup"
+}
+
 // GetTotalDuration 计算总处理时长
 func (pt *ProcessTrace) GetTotalDuration() int64 {
 	if pt.EndTime > 0 && pt.StartTime > 0 {
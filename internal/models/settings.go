@@ -53,10 +53,25 @@ type AiConfig struct {
 
 // ProviderConfig 单个 AI Provider 的配置
 type ProviderConfig struct {
-	Type   string   `json:"type"`   // Provider 类型：dify | openai
+	Type   string   `json:"type"`   // Provider 类型：dify | openai | anthropic | gemini
 	Url    string   `json:"url"`    // API 端点地址
 	AppKey string   `json:"appKey"` // API 密钥
 	Models []string `json:"models"` // 该 Provider 支持的模型列表
+	// 自定义请求头，附加到该 Provider 的每个请求
+	Headers map[string]string `json:"headers,omitempty"`
+	// 各模型单价，key 为模型名称，用于估算调用费用
+	Prices map[string]ModelPrice `json:"prices,omitempty"`
+}
+
+// ModelPrice 模型单价，单位为每百万 token 的价格，币种由配置方自行约定
+type ModelPrice struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
+}
+
+// Cost 按单价计算费用
+func (p ModelPrice) Cost(promptTokens, completionTokens int) float64 {
+	return (float64(promptTokens)*p.Prompt + float64(completionTokens)*p.Completion) / 1e6
 }
 
 type LdapConfig struct {
@@ -0,0 +1,33 @@
+package models
+
+// TeamsMsgTemplate Microsoft Teams Incoming Webhook 旧版 MessageCard 消息
+type TeamsMsgTemplate struct {
+	Type       string         `json:"@type"`
+	Context    string         `json:"@context"`
+	ThemeColor string         `json:"themeColor,omitempty"`
+	Summary    string         `json:"summary"`
+	Title      string         `json:"title,omitempty"`
+	Text       string         `json:"text,omitempty"`
+	Sections   []TeamsSection `json:"sections,omitempty"`
+}
+
+type TeamsSection struct {
+	ActivityTitle string      `json:"activityTitle,omitempty"`
+	Text          string      `json:"text,omitempty"`
+	Facts         []TeamsFact `json:"facts,omitempty"`
+	Markdown      bool        `json:"markdown"`
+}
+
+type TeamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// NewTeamsMsgTemplate 创建 MessageCard 消息
+func NewTeamsMsgTemplate(summary string) TeamsMsgTemplate {
+	return TeamsMsgTemplate{
+		Type:    "MessageCard",
+		Context: "http://schema.org/extensions",
+		Summary: summary,
+	}
+}
@@ -33,6 +33,7 @@ func (r *ApiRegistry) GetAllApiEndpoints() []ApiEndpoint {
 	return []ApiEndpoint{
 		// AI接口
 		{"/api/w8t/ai/chat", "POST", "AI聊天", "AI助手"},
+		{"/api/w8t/ai/usage", "GET", "获取AI调用用量", "AI助手"},
 
 		// 审计日志
 		{"/api/w8t/auditLog/listAuditLog", "GET", "获取审计日志列表", "审计日志"},
@@ -91,6 +92,7 @@ func (r *ApiRegistry) GetAllApiEndpoints() []ApiEndpoint {
 		{"/api/w8t/datasource/dataSourceDelete", "POST", "删除数据源", "数据源管理"},
 		{"/api/w8t/datasource/dataSourceList", "GET", "获取数据源列表", "数据源管理"},
 		{"/api/w8t/datasource/dataSourceGet", "GET", "获取数据源详情", "数据源管理"},
+		{"/api/w8t/datasource/dataSourceHealth", "GET", "批量检查数据源健康状态", "数据源管理"},
 		{"/api/w8t/datasource/promQuery", "GET", "Prometheus查询", "数据源管理"},
 		{"/api/w8t/datasource/promQueryRange", "GET", "Prometheus范围查询", "数据源管理"},
 		{"/api/w8t/datasource/promLabelValues", "GET", "获取Prometheus标签值", "数据源管理"},
@@ -42,6 +42,7 @@ type (
 
 		// 数据清理相关操作（在同步时自动调用）
 		CleanupDuplicateTargets(tenantId string) (int64, error)
+		CountDuplicateTargets(tenantId string) (int64, error)
 	}
 )
 
@@ -466,3 +467,23 @@ func (c consulRepo) CleanupDuplicateTargets(tenantId string) (int64, error) {
 	result := c.db.Exec(query, tenantId)
 	return result.RowsAffected, result.Error
 }
+
+// CountDuplicateTargets 统计 CleanupDuplicateTargets 将会删除的重复记录数，不修改数据
+// 用于同步预览，筛选条件与 CleanupDuplicateTargets 保持一致
+func (c consulRepo) CountDuplicateTargets(tenantId string) (int64, error) {
+	query := `
+		SELECT COUNT(*)
+		FROM consul_target ct
+		WHERE ct.tenant_id = ?
+		AND ct.id < (
+			SELECT MAX(id)
+			FROM consul_target ct2
+			WHERE ct2.tenant_id = ct.tenant_id
+			AND ct2.service_id = ct.service_id
+		)
+	`
+
+	var count int64
+	err := c.db.Raw(query, tenantId).Scan(&count).Error
+	return count, err
+}
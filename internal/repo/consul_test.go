@@ -0,0 +1,70 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// newDuplicateTargetsRepo 创建包含重复记录的内存库，表结构不带唯一索引以便写入重复数据
+func newDuplicateTargetsRepo(t *testing.T) consulRepo {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec(`CREATE TABLE consul_target (id INTEGER PRIMARY KEY, tenant_id TEXT, service_id TEXT)`).Error; err != nil {
+		t.Fatal(err)
+	}
+	rows := []struct {
+		id        int
+		tenantId  string
+		serviceId string
+	}{
+		{1, "t1", "node-1"},
+		{2, "t1", "node-1"},
+		{3, "t1", "node-1"},
+		{4, "t1", "node-2"},
+		{5, "t2", "node-1"},
+		{6, "t2", "node-1"},
+	}
+	for _, r := range rows {
+		if err := db.Exec(`INSERT INTO consul_target (id, tenant_id, service_id) VALUES (?, ?, ?)`, r.id, r.tenantId, r.serviceId).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return consulRepo{entryRepo{db: db}}
+}
+
+func TestCountDuplicateTargetsMatchesCleanup(t *testing.T) {
+	r := newDuplicateTargetsRepo(t)
+
+	count, err := r.CountDuplicateTargets("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("count got %d, want 2", count)
+	}
+
+	var total int64
+	r.db.Table("consul_target").Count(&total)
+	if total != 6 {
+		t.Fatalf("count must not modify data, got %d rows", total)
+	}
+
+	deleted, err := r.CleanupDuplicateTargets("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted != count {
+		t.Fatalf("cleanup deleted %d, count reported %d", deleted, count)
+	}
+
+	var kept []int64
+	r.db.Table("consul_target").Where("tenant_id = ?", "t1").Order("id").Pluck("id", &kept)
+	if len(kept) != 2 || kept[0] != 3 || kept[1] != 4 {
+		t.Fatalf("got kept ids %v, want [3 4]", kept)
+	}
+}
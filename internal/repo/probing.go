@@ -40,7 +40,7 @@ func (p ProbingRepo) Create(d models.ProbingRule) error {
 
 	err := p.g.Create(models.ProbingRule{}, d)
 	if err != nil {
-		logc.Errorf(context.Background(), err.Error())
+		logc.Error(context.Background(), err.Error())
 		return err
 	}
 	return nil
@@ -57,7 +57,7 @@ func (p ProbingRepo) Update(d models.ProbingRule) error {
 	}
 	err := p.g.Updates(u)
 	if err != nil {
-		logc.Errorf(context.Background(), err.Error())
+		logc.Error(context.Background(), err.Error())
 		return err
 	}
 	return nil
@@ -73,7 +73,7 @@ func (p ProbingRepo) Delete(tenantId, ruleId string) error {
 	}
 	err := p.g.Delete(del)
 	if err != nil {
-		logc.Errorf(context.Background(), err.Error())
+		logc.Error(context.Background(), err.Error())
 		return err
 	}
 	return nil
@@ -131,7 +131,7 @@ func (p ProbingRepo) Search(tenantId, ruleId string) (models.ProbingRule, error)
 func (p ProbingRepo) AddRecord(history models.ProbingHistory) error {
 	err := p.g.Create(models.ProbingHistory{}, history)
 	if err != nil {
-		logc.Errorf(context.Background(), err.Error())
+		logc.Error(context.Background(), err.Error())
 		return err
 	}
 	return nil
@@ -175,7 +175,7 @@ func (p ProbingRepo) DeleteRecord() error {
 	}
 	err := p.g.Delete(del)
 	if err != nil {
-		logc.Errorf(context.Background(), err.Error())
+		logc.Error(context.Background(), err.Error())
 		return err
 	}
 	return nil
@@ -2,10 +2,15 @@ package repo
 
 import (
 	"alertHub/internal/models"
+	"fmt"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// operationLogRollupBatchSize 每批汇总的明细日志条数
+const operationLogRollupBatchSize = 1000
+
 type (
 	ProcessTraceRepo interface {
 		// 创建处理流程追踪记录
@@ -28,6 +33,24 @@ type (
 
 		// 删除处理流程记录
 		Delete(tenantId, processId string) error
+
+		// 获取租户下的全部处理流程记录
+		ListByTenant(tenantId string) ([]models.ProcessTrace, error)
+
+		// 统计时间范围内的处理流程数，status 为空时不限状态
+		CountInRange(tenantId string, status models.ProcessTraceStatus, startTime, endTime int64) (int64, error)
+
+		// 统计时间范围内已完成流程的平均处理时长（秒）
+		AvgCompletedDuration(tenantId string, startTime, endTime int64) (float64, error)
+
+		// 统计时间范围内各状态的流程数
+		StatusDistribution(tenantId string, startTime, endTime int64) ([]map[string]interface{}, error)
+
+		// 根据指纹查询当前告警事件
+		GetCurEventByFingerprint(tenantId, fingerprint string) (models.AlertCurEvent, error)
+
+		// 根据事件ID查询规则信息，优先历史事件表，其次当前事件表
+		GetRuleInfoByEventId(tenantId, eventId string) (ruleId, ruleName string, err error)
 	}
 
 	ProcessOperationLogRepo interface {
@@ -39,6 +62,21 @@ type (
 
 		// 根据流程ID获取操作日志
 		GetByProcessId(tenantId, processId string, page, pageSize int) ([]models.ProcessOperationLog, int64, error)
+
+		// 获取存在操作日志的租户ID
+		ListTenantIds() ([]string, error)
+
+		// 将早于 before 的明细日志按天汇总后删除，返回处理的明细条数
+		RollupBefore(tenantId string, before int64) (int64, error)
+
+		// 统计时间范围内明细日志的各操作类型次数
+		CountByOperationType(tenantId string, startTime, endTime int64) (map[string]int64, error)
+
+		// 统计日期范围内汇总日志的各操作类型次数
+		CountRollupByOperationType(tenantId, startDate, endDate string) (map[string]int64, error)
+
+		// 获取指定日期的汇总日志
+		ListRollupsByDates(tenantId string, dates []string) ([]models.ProcessOperationLogRollup, error)
 	}
 
 	processTraceRepo struct {
@@ -181,6 +219,65 @@ func (r *processTraceRepo) Delete(tenantId, processId string) error {
 	return r.db.Where("tenant_id = ? AND id = ?", tenantId, processId).Delete(&models.ProcessTrace{}).Error
 }
 
+func (r *processTraceRepo) ListByTenant(tenantId string) ([]models.ProcessTrace, error) {
+	var processes []models.ProcessTrace
+	err := r.db.Where("tenant_id = ?", tenantId).Find(&processes).Error
+	return processes, err
+}
+
+func (r *processTraceRepo) CountInRange(tenantId string, status models.ProcessTraceStatus, startTime, endTime int64) (int64, error) {
+	var count int64
+	db := r.db.Model(&models.ProcessTrace{}).Where("tenant_id = ? AND created_at BETWEEN ? AND ?", tenantId, startTime, endTime)
+	if status != "" {
+		db = db.Where("current_status = ?", status)
+	}
+	err := db.Count(&count).Error
+	return count, err
+}
+
+func (r *processTraceRepo) AvgCompletedDuration(tenantId string, startTime, endTime int64) (float64, error) {
+	var avgDuration float64
+	err := r.db.Model(&models.ProcessTrace{}).
+		Select("COALESCE(AVG(end_time - start_time), 0) as avg_duration").
+		Where("tenant_id = ? AND current_status = ? AND end_time > 0 AND created_at BETWEEN ? AND ?", tenantId, models.ProcessStatusCompleted, startTime, endTime).
+		Scan(&avgDuration).Error
+	return avgDuration, err
+}
+
+func (r *processTraceRepo) StatusDistribution(tenantId string, startTime, endTime int64) ([]map[string]interface{}, error) {
+	var distribution []map[string]interface{}
+	err := r.db.Model(&models.ProcessTrace{}).
+		Select("current_status, COUNT(*) as count").
+		Where("tenant_id = ? AND created_at BETWEEN ? AND ?", tenantId, startTime, endTime).
+		Group("current_status").
+		Scan(&distribution).Error
+	return distribution, err
+}
+
+func (r *processTraceRepo) GetCurEventByFingerprint(tenantId, fingerprint string) (models.AlertCurEvent, error) {
+	var event models.AlertCurEvent
+	err := r.db.Table("alert_cur_events").Where("tenant_id = ? AND fingerprint = ?", tenantId, fingerprint).First(&event).Error
+	return event, err
+}
+
+func (r *processTraceRepo) GetRuleInfoByEventId(tenantId, eventId string) (string, string, error) {
+	var historyEvent models.AlertHisEvent
+	err := r.db.Table("alert_his_events").Where("tenant_id = ? AND event_id = ?", tenantId, eventId).
+		Select("rule_id, rule_name").First(&historyEvent).Error
+	if err == nil && historyEvent.RuleName != "" {
+		return historyEvent.RuleId, historyEvent.RuleName, nil
+	}
+
+	var currentEvent models.AlertCurEvent
+	err = r.db.Table("alert_cur_events").Where("tenant_id = ? AND event_id = ?", tenantId, eventId).
+		Select("rule_id, rule_name").First(&currentEvent).Error
+	if err != nil {
+		return "", "", err
+	}
+
+	return currentEvent.RuleId, currentEvent.RuleName, nil
+}
+
 // ProcessOperationLogRepo 实现
 
 func (r *processOperationLogRepo) Create(log *models.ProcessOperationLog) error {
@@ -230,3 +327,180 @@ func (r *processOperationLogRepo) GetByProcessId(tenantId, processId string, pag
 
 	return logs, total, nil
 }
+
+func (r *processOperationLogRepo) ListTenantIds() ([]string, error) {
+	var tenantIds []string
+	err := r.db.Model(&models.ProcessOperationLog{}).Distinct("tenant_id").Pluck("tenant_id", &tenantIds).Error
+	return tenantIds, err
+}
+
+func (r *processOperationLogRepo) RollupBefore(tenantId string, before int64) (int64, error) {
+	var total int64
+	for {
+		var logs []models.ProcessOperationLog
+		err := r.db.Model(&models.ProcessOperationLog{}).
+			Where("tenant_id = ? AND operation_time < ?", tenantId, before).
+			Order("operation_time ASC").
+			Limit(operationLogRollupBatchSize).
+			Find(&logs).Error
+		if err != nil {
+			return total, err
+		}
+		if len(logs) == 0 {
+			return total, nil
+		}
+
+		rollups := BuildOperationLogRollups(logs)
+		ids := make([]string, 0, len(logs))
+		for _, log := range logs {
+			ids = append(ids, log.ID)
+		}
+
+		// 汇总写入与明细删除在同一事务中完成，避免重复计数
+		err = r.db.Transaction(func(tx *gorm.DB) error {
+			err := tx.Model(&models.ProcessOperationLogRollup{}).
+				Clauses(clause.OnConflict{
+					Columns: []clause.Column{{Name: "tenant_id"}, {Name: "process_id"}, {Name: "date"}, {Name: "operation_type"}},
+					DoUpdates: rollupUpsertAssignments(tx),
+				}).
+				Create(&rollups).Error
+			if err != nil {
+				return err
+			}
+
+			return tx.Where("id IN ?", ids).Delete(&models.ProcessOperationLog{}).Error
+		})
+		if err != nil {
+			return total, err
+		}
+
+		total += int64(len(logs))
+		if len(logs) < operationLogRollupBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// rollupUpsertAssignments 汇总记录冲突时的累加表达式
+// MySQL 通过 VALUES() 引用待插入的值，PostgreSQL/SQLite 通过 excluded 引用，并需用表名限定已有列避免歧义
+func rollupUpsertAssignments(db *gorm.DB) clause.Set {
+	table := (&models.ProcessOperationLogRollup{}).TableName()
+	existing := func(column string) string { return table + "." + column }
+	inserted := func(column string) string { return "excluded." + column }
+	least, greatest := "LEAST", "GREATEST"
+
+	switch db.Dialector.Name() {
+	case "mysql":
+		existing = func(column string) string { return column }
+		inserted = func(column string) string { return "VALUES(" + column + ")" }
+	case "sqlite":
+		least, greatest = "MIN", "MAX"
+	}
+
+	return clause.Assignments(map[string]interface{}{
+		"count":      gorm.Expr(fmt.Sprintf("%s + %s", existing("count"), inserted("count"))),
+		"first_time": gorm.Expr(fmt.Sprintf("%s(%s, %s)", least, existing("first_time"), inserted("first_time"))),
+		"last_time":  gorm.Expr(fmt.Sprintf("%s(%s, %s)", greatest, existing("last_time"), inserted("last_time"))),
+	})
+}
+
+// BuildOperationLogRollups 按 流程/日期/操作类型 聚合明细日志
+func BuildOperationLogRollups(logs []models.ProcessOperationLog) []models.ProcessOperationLogRollup {
+	type rollupKey struct {
+		processId     string
+		date          string
+		operationType string
+	}
+
+	index := make(map[rollupKey]int)
+	var rollups []models.ProcessOperationLogRollup
+	for _, log := range logs {
+		key := rollupKey{
+			processId:     log.ProcessId,
+			date:          models.RollupDate(log.OperationTime),
+			operationType: log.OperationType,
+		}
+
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(rollups)
+			rollups = append(rollups, models.ProcessOperationLogRollup{
+				TenantId:      log.TenantId,
+				ProcessId:     log.ProcessId,
+				Date:          key.date,
+				OperationType: log.OperationType,
+				EventId:       log.EventId,
+				Count:         1,
+				FirstTime:     log.OperationTime,
+				LastTime:      log.OperationTime,
+			})
+			continue
+		}
+
+		rollups[i].Count++
+		if log.OperationTime < rollups[i].FirstTime {
+			rollups[i].FirstTime = log.OperationTime
+		}
+		if log.OperationTime > rollups[i].LastTime {
+			rollups[i].LastTime = log.OperationTime
+		}
+	}
+
+	return rollups
+}
+
+func (r *processOperationLogRepo) CountByOperationType(tenantId string, startTime, endTime int64) (map[string]int64, error) {
+	var rows []struct {
+		OperationType string
+		Count         int64
+	}
+
+	err := r.db.Model(&models.ProcessOperationLog{}).
+		Select("operation_type, COUNT(*) as count").
+		Where("tenant_id = ? AND operation_time >= ? AND operation_time <= ?", tenantId, startTime, endTime).
+		Group("operation_type").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		result[row.OperationType] = row.Count
+	}
+	return result, nil
+}
+
+func (r *processOperationLogRepo) CountRollupByOperationType(tenantId, startDate, endDate string) (map[string]int64, error) {
+	var rows []struct {
+		OperationType string
+		Count         int64
+	}
+
+	err := r.db.Model(&models.ProcessOperationLogRollup{}).
+		Select("operation_type, SUM(count) as count").
+		Where("tenant_id = ? AND date >= ? AND date <= ?", tenantId, startDate, endDate).
+		Group("operation_type").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		result[row.OperationType] = row.Count
+	}
+	return result, nil
+}
+
+func (r *processOperationLogRepo) ListRollupsByDates(tenantId string, dates []string) ([]models.ProcessOperationLogRollup, error) {
+	var rollups []models.ProcessOperationLogRollup
+	if len(dates) == 0 {
+		return rollups, nil
+	}
+
+	err := r.db.Model(&models.ProcessOperationLogRollup{}).
+		Where("tenant_id = ? AND date IN ?", tenantId, dates).
+		Find(&rollups).Error
+	return rollups, err
+}
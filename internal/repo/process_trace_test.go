@@ -0,0 +1,111 @@
+package repo
+
+import (
+	"alertHub/internal/models"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// newOperationLogRepo 创建基于内存 SQLite 的操作日志库
+func newOperationLogRepo(t *testing.T) *processOperationLogRepo {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.ProcessOperationLog{}, &models.ProcessOperationLogRollup{}); err != nil {
+		t.Fatal(err)
+	}
+	return &processOperationLogRepo{db: db}
+}
+
+// seedLogs 写入指定时间点的明细日志
+func seedLogs(t *testing.T, r *processOperationLogRepo, tenantId, operationType string, times ...time.Time) {
+	t.Helper()
+	for _, at := range times {
+		var n int64
+		r.db.Model(&models.ProcessOperationLog{}).Count(&n)
+		err := r.db.Create(&models.ProcessOperationLog{
+			ID:            fmt.Sprintf("log-%d", n),
+			TenantId:      tenantId,
+			ProcessId:     "p-1",
+			EventId:       "e-1",
+			OperationType: operationType,
+			OperationTime: at.Unix(),
+		}).Error
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRollupBeforeSQLite(t *testing.T) {
+	// 汇总日期按 UTC 划分，与服务器时区无关
+	oldLocal := time.Local
+	time.Local = time.FixedZone("UTC+8", 8*3600)
+	t.Cleanup(func() { time.Local = oldLocal })
+
+	r := newOperationLogRepo(t)
+	at := func(d, h int) time.Time { return time.Date(2025, 3, d, h, 0, 0, 0, time.UTC) }
+
+	// 超过一个批次的明细，覆盖分批汇总
+	var bulk []time.Time
+	for i := 0; i < operationLogRollupBatchSize+200; i++ {
+		bulk = append(bulk, at(1, 1).Add(time.Duration(i)*time.Second))
+	}
+	seedLogs(t, r, "t-1", "update_status", bulk...)
+	// 23:30 UTC 在 UTC+8 下属于次日，汇总日期仍应为 03-01
+	seedLogs(t, r, "t-1", "update_status", at(1, 23).Add(30*time.Minute))
+	seedLogs(t, r, "t-1", "create_process", at(2, 9), at(3, 9))
+	seedLogs(t, r, "t-2", "update_status", at(1, 9))
+
+	count, err := r.RollupBefore("t-1", at(3, 0).Unix())
+	if err != nil {
+		t.Fatalf("RollupBefore err: %v", err)
+	}
+	if want := int64(operationLogRollupBatchSize + 202); count != want {
+		t.Fatalf("汇总明细数 got %d, want %d", count, want)
+	}
+
+	// 再次汇总同一天的新增明细，应通过 upsert 累加到已有汇总记录
+	seedLogs(t, r, "t-1", "update_status", at(1, 0))
+	if _, err := r.RollupBefore("t-1", at(3, 0).Unix()); err != nil {
+		t.Fatalf("second RollupBefore err: %v", err)
+	}
+
+	rollups, err := r.ListRollupsByDates("t-1", []string{"2025-03-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rollups) != 1 {
+		t.Fatalf("03-01 应只有一条汇总记录, got %+v", rollups)
+	}
+	got := rollups[0]
+	if got.Count != int64(operationLogRollupBatchSize+202) ||
+		got.FirstTime != at(1, 0).Unix() || got.LastTime != at(1, 23).Add(30*time.Minute).Unix() {
+		t.Fatalf("汇总记录 got count=%d first=%d last=%d", got.Count, got.FirstTime, got.LastTime)
+	}
+
+	// 汇总后明细只保留未过期及其他租户的数据
+	var remaining []models.ProcessOperationLog
+	if err := r.db.Order("tenant_id").Find(&remaining).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(remaining) != 2 || remaining[0].OperationTime != at(3, 9).Unix() || remaining[1].TenantId != "t-2" {
+		t.Fatalf("剩余明细 got %+v", remaining)
+	}
+
+	counts, err := r.CountRollupByOperationType("t-1", "2025-03-01", "2025-03-02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int64{"update_status": int64(operationLogRollupBatchSize + 202), "create_process": 1}
+	if !reflect.DeepEqual(counts, want) {
+		t.Fatalf("CountRollupByOperationType got %v, want %v", counts, want)
+	}
+}
@@ -2,10 +2,14 @@ package services
 
 import (
 	"alertHub/internal/ctx"
+	"alertHub/internal/models"
 	"alertHub/internal/types"
 	"alertHub/pkg/ai"
 	"fmt"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 )
 
 type (
@@ -15,10 +19,79 @@ type (
 
 	InterAiService interface {
 		// StreamChat 返回流式数据通道，用于真正的实时流式传输
-		StreamChat(req interface{}) (<-chan string, interface{})
+		StreamChat(req interface{}) (<-chan ai.StreamEvent, interface{})
+		// Usage 获取租户最近每天的调用用量与估算费用
+		Usage(req interface{}) (interface{}, interface{})
 	}
 )
 
+const (
+	// aiUsageRetentionDays 用量统计保留天数
+	aiUsageRetentionDays = 30
+	// aiUsageDefaultDays 默认查询天数
+	aiUsageDefaultDays = 7
+	aiUsageDateLayout  = "2006-01-02"
+)
+
+// aiUsageStats 按 租户 -> 日期 统计的调用用量，仅保存在内存中，重启后重新计数
+var aiUsageStats = struct {
+	sync.Mutex
+	tenants map[string]map[string]*types.ResponseAiUsage
+}{tenants: make(map[string]map[string]*types.ResponseAiUsage)}
+
+// recordAiUsage 累加租户当天的用量与费用，并清理超过保留天数的记录
+func recordAiUsage(tenantId string, now time.Time, usage ai.Usage, price models.ModelPrice) {
+	aiUsageStats.Lock()
+	defer aiUsageStats.Unlock()
+
+	days, ok := aiUsageStats.tenants[tenantId]
+	if !ok {
+		days = make(map[string]*types.ResponseAiUsage)
+		aiUsageStats.tenants[tenantId] = days
+	}
+
+	date := now.Format(aiUsageDateLayout)
+	stat, ok := days[date]
+	if !ok {
+		stat = &types.ResponseAiUsage{Date: date}
+		days[date] = stat
+
+		oldest := now.AddDate(0, 0, -aiUsageRetentionDays).Format(aiUsageDateLayout)
+		for d := range days {
+			if d <= oldest {
+				delete(days, d)
+			}
+		}
+	}
+
+	stat.Requests++
+	if usage.Estimated {
+		stat.EstimatedRequests++
+	}
+	stat.PromptTokens += int64(usage.PromptTokens)
+	stat.CompletionTokens += int64(usage.CompletionTokens)
+	stat.Cost += price.Cost(usage.PromptTokens, usage.CompletionTokens)
+}
+
+// listAiUsage 获取租户最近 days 天有调用的用量，按日期倒序
+func listAiUsage(tenantId string, now time.Time, days int) []types.ResponseAiUsage {
+	aiUsageStats.Lock()
+	defer aiUsageStats.Unlock()
+
+	oldest := now.AddDate(0, 0, -days).Format(aiUsageDateLayout)
+	list := make([]types.ResponseAiUsage, 0, days)
+	for date, stat := range aiUsageStats.tenants[tenantId] {
+		if date > oldest {
+			list = append(list, *stat)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Date > list[j].Date
+	})
+	return list
+}
+
 func newInterAiService(ctx *ctx.Context) InterAiService {
 	return &aiService{
 		ctx: ctx,
@@ -60,7 +133,7 @@ func buildPrompt(template string, r *types.RequestAiChatContent) string {
 }
 
 // StreamChat 流式聊天方法 - 返回通道支持实时流式传输
-func (a aiService) StreamChat(req interface{}) (<-chan string, interface{}) {
+func (a aiService) StreamChat(req interface{}) (<-chan ai.StreamEvent, interface{}) {
 	setting, err := a.ctx.DB.Setting().Get()
 	if err != nil {
 		return nil, err
@@ -98,6 +171,10 @@ func (a aiService) StreamChat(req interface{}) (<-chan string, interface{}) {
 		Model:     r.Model,
 		Timeout:   setting.AiConfig.Timeout,
 		MaxTokens: setting.AiConfig.MaxTokens,
+		Headers:   providerConfig.Headers,
+		OnUsage: func(usage ai.Usage) {
+			recordAiUsage(r.TenantId, time.Now(), usage, providerConfig.Prices[r.Model])
+		},
 	}
 
 	aiClient, err := ai.NewAiClient(aiConfig)
@@ -123,3 +200,18 @@ func (a aiService) StreamChat(req interface{}) (<-chan string, interface{}) {
 
 	return streamChan, nil
 }
+
+// Usage 获取租户最近每天的调用用量与估算费用
+func (a aiService) Usage(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestAiUsageQuery)
+
+	days := r.Days
+	if days <= 0 {
+		days = aiUsageDefaultDays
+	}
+	if days > aiUsageRetentionDays {
+		days = aiUsageRetentionDays
+	}
+
+	return listAiUsage(r.TenantId, time.Now(), days), nil
+}
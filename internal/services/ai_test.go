@@ -0,0 +1,61 @@
+package services
+
+import (
+	"alertHub/internal/models"
+	"alertHub/internal/types"
+	"alertHub/pkg/ai"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAiUsagePerTenantPerDay(t *testing.T) {
+	// 统计为包级变量，清理后测试可重复执行
+	for _, tenant := range []string{"t-usage-a", "t-usage-b"} {
+		aiUsageStats.Lock()
+		delete(aiUsageStats.tenants, tenant)
+		aiUsageStats.Unlock()
+	}
+
+	price := models.ModelPrice{Prompt: 2, Completion: 8}
+	today := time.Date(2026, 3, 31, 10, 0, 0, 0, time.Local)
+	yesterday := today.AddDate(0, 0, -1)
+
+	recordAiUsage("t-usage-a", yesterday, ai.Usage{PromptTokens: 1000, CompletionTokens: 500}, price)
+	recordAiUsage("t-usage-a", today, ai.Usage{PromptTokens: 1000, CompletionTokens: 500}, price)
+	recordAiUsage("t-usage-a", today, ai.Usage{PromptTokens: 500, CompletionTokens: 100, Estimated: true}, price)
+	recordAiUsage("t-usage-b", today, ai.Usage{PromptTokens: 1, CompletionTokens: 1}, models.ModelPrice{})
+
+	got := listAiUsage("t-usage-a", today, 7)
+	if len(got) != 2 || got[0].Date != "2026-03-31" || got[1].Date != "2026-03-30" {
+		t.Fatalf("got %+v, want today then yesterday", got)
+	}
+
+	want := types.ResponseAiUsage{Date: "2026-03-31", Requests: 2, EstimatedRequests: 1, PromptTokens: 1500, CompletionTokens: 600}
+	cost := got[0].Cost
+	got[0].Cost = 0
+	if got[0] != want {
+		t.Fatalf("got %+v, want %+v", got[0], want)
+	}
+	// (1500*2 + 600*8) / 1e6
+	if math.Abs(cost-0.0078) > 1e-12 {
+		t.Fatalf("cost got %v, want 0.0078", cost)
+	}
+
+	if got := listAiUsage("t-usage-a", today, 1); len(got) != 1 || got[0].Date != "2026-03-31" {
+		t.Fatalf("days=1 got %+v, want only today", got)
+	}
+	if got := listAiUsage("t-usage-b", today, 7); len(got) != 1 || got[0].Cost != 0 {
+		t.Fatalf("tenant b got %+v, want a separate zero-cost record", got)
+	}
+
+	// 保留含当天在内的 aiUsageRetentionDays 天，更早的记录在新的一天首次记录时清理
+	recordAiUsage("t-usage-a", today.AddDate(0, 0, aiUsageRetentionDays-1), ai.Usage{PromptTokens: 1}, price)
+	aiUsageStats.Lock()
+	_, kept := aiUsageStats.tenants["t-usage-a"]["2026-03-31"]
+	_, stale := aiUsageStats.tenants["t-usage-a"]["2026-03-30"]
+	aiUsageStats.Unlock()
+	if stale || !kept {
+		t.Fatalf("retention got stale=%v kept=%v, want only days outside the last %d removed", stale, kept, aiUsageRetentionDays)
+	}
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"alertHub/alert"
 	"alertHub/internal/ctx"
 	"alertHub/internal/models"
 	consulclient "alertHub/pkg/consul"
@@ -8,6 +9,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/zeromicro/go-zero/core/logc"
@@ -23,9 +25,9 @@ type (
 		// 目标管理
 		GetAllTargets(tenantId string, filters map[string]interface{}, page, pageSize int) (interface{}, interface{})
 		GetTargetById(id int64) (interface{}, interface{})
-		DeregisterTarget(tenantId string, targetId int64, reason string, userId string) (interface{}, interface{})
+		DeregisterTarget(tenantId string, targetId int64, reason string, userId string, consulDatasourceId string) (interface{}, interface{})
 		ReRegisterTarget(tenantId string, targetId int64, userId string) (interface{}, interface{})
-		RegisterTarget(tenantId string, serviceID, serviceName, address string, port int, job string, tags []string, labels map[string]string) (interface{}, interface{})
+		RegisterTarget(tenantId string, serviceID, serviceName, address string, port int, job string, tags []string, labels map[string]string, check *consulclient.HealthCheckConfig, consulDatasourceId string) (interface{}, interface{})
 
 		// 标签管理
 		GetTargetsByTag(tenantId string, tag string, page, pageSize int) (interface{}, interface{})
@@ -33,10 +35,14 @@ type (
 		UpdateTargetTags(tenantId string, targetId int64, labels map[string]interface{}) (interface{}, interface{})
 
 		// 同步管理
-		SyncTargets(tenantId string) (interface{}, interface{})
+		SyncTargets(tenantId string, consulDatasourceId string, dryRun bool) (interface{}, interface{})
 
 		// 注销记录管理
 		GetOfflineLogs(tenantId string, page, pageSize int) (interface{}, interface{})
+
+		// 目录变更自动同步
+		WatchTargets(datasource models.AlertDataSource)
+		StopWatchTargets(datasourceId string)
 	}
 )
 
@@ -95,26 +101,59 @@ func (c *consulService) labelsEqual(labels1, labels2 map[string]interface{}) boo
 }
 
 // getConsulConfigFromDataSource 从数据源系统中获取 Consul 配置
-// 返回从 AlertDataSource 中类型为 "consul" 的数据源配置
-func (c *consulService) getConsulConfigFromDataSource(tenantId string) (*models.DsConsulConfig, error) {
+// 返回从 AlertDataSource 中类型为 "consul" 的数据源配置，datasourceId 为空时使用租户的默认 Consul 数据源
+func (c *consulService) getConsulConfigFromDataSource(tenantId, datasourceId string) (*models.DsConsulConfig, error) {
+	dataSource, _, err := c.resolveConsulDatasource(tenantId, datasourceId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dataSource.ConsulConfig, nil
+}
+
+// resolveConsulDatasource 获取指定的 Consul 数据源，datasourceId 为空时返回第一个（默认）Consul 数据源
+// 第二个返回值表示该数据源是否为租户的默认 Consul 数据源，未记录数据源ID的历史目标归属于默认数据源
+func (c *consulService) resolveConsulDatasource(tenantId, datasourceId string) (models.AlertDataSource, bool, error) {
 	// 从数据源管理中查询类型为 "consul" 的数据源
 	dataSources, err := c.ctx.DB.Datasource().List(tenantId, "", "consul", "")
 	if err != nil {
-		return nil, fmt.Errorf("查询 Consul 数据源失败: %w", err)
+		return models.AlertDataSource{}, false, fmt.Errorf("查询 Consul 数据源失败: %w", err)
 	}
 
 	// 检查是否找到 Consul 数据源
 	if len(dataSources) == 0 {
-		return nil, fmt.Errorf("请先在数据源管理中配置 Consul 数据源")
+		return models.AlertDataSource{}, false, fmt.Errorf("请先在数据源管理中配置 Consul 数据源")
 	}
 
-	// 获取第一个 Consul 数据源的配置
-	dataSource := dataSources[0]
+	// 未指定时使用第一个 Consul 数据源
+	index := 0
+	if datasourceId != "" {
+		index = -1
+		for i, ds := range dataSources {
+			if ds.ID == datasourceId {
+				index = i
+				break
+			}
+		}
+		if index == -1 {
+			return models.AlertDataSource{}, false, fmt.Errorf("Consul 数据源 %s 不存在", datasourceId)
+		}
+	}
+
+	dataSource := dataSources[index]
 	if dataSource.ConsulConfig.Address == "" {
-		return nil, fmt.Errorf("Consul 数据源配置不完整，缺少服务器地址")
+		return models.AlertDataSource{}, false, fmt.Errorf("Consul 数据源配置不完整，缺少服务器地址")
 	}
 
-	return &dataSource.ConsulConfig, nil
+	return dataSource, index == 0, nil
+}
+
+// belongsToConsulDatasource 判断目标是否属于指定的 Consul 数据源
+func belongsToConsulDatasource(target models.ConsulTarget, datasourceId string, isDefault bool) bool {
+	if target.DatasourceId == "" {
+		return isDefault
+	}
+	return target.DatasourceId == datasourceId
 }
 
 // GetAllTargets 获取所有目标机器
@@ -204,7 +243,7 @@ func (c *consulService) GetTargetById(id int64) (interface{}, interface{}) {
 }
 
 // DeregisterTarget 注销目标机器并清理告警
-func (c *consulService) DeregisterTarget(tenantId string, targetId int64, reason string, userId string) (interface{}, interface{}) {
+func (c *consulService) DeregisterTarget(tenantId string, targetId int64, reason string, userId string, consulDatasourceId string) (interface{}, interface{}) {
 	// 获取目标信息
 	target, err := c.ctx.DB.Consul().GetTargetById(targetId)
 	if err != nil {
@@ -215,8 +254,11 @@ func (c *consulService) DeregisterTarget(tenantId string, targetId int64, reason
 		return nil, fmt.Errorf("目标不存在")
 	}
 
-	// 从数据源系统中获取 Consul 配置
-	config, err := c.getConsulConfigFromDataSource(tenantId)
+	// 从数据源系统中获取 Consul 配置，未指定时使用目标注册时所在的 Consul 数据源
+	if consulDatasourceId == "" {
+		consulDatasourceId = target.DatasourceId
+	}
+	config, err := c.getConsulConfigFromDataSource(tenantId, consulDatasourceId)
 	if err != nil {
 		// 未配置 Consul 数据源，仍允许在本地数据库中标记为已注销
 		// 但无法在 Consul 中进行注销操作
@@ -239,8 +281,7 @@ func (c *consulService) DeregisterTarget(tenantId string, targetId int64, reason
 	}
 
 	// 清理相关的告警事件
-	alertEventsCleared := 0
-	// TODO: 根据 target.Instance 清理 AlertCurEvent 中相关的告警
+	alertEventsCleared := c.clearAlertEventsByInstance(tenantId, target.Instance)
 
 	// 更新目标状态为已注销（使用 Consul 健康检查状态）
 	target.Status = "no checks"
@@ -269,6 +310,68 @@ func (c *consulService) DeregisterTarget(tenantId string, targetId int64, reason
 	}, nil
 }
 
+// clearAlertEventsByInstance 清理租户下所有故障中心中 instance 标签与目标一致的活跃告警，返回清理数量
+func (c *consulService) clearAlertEventsByInstance(tenantId, instance string) int {
+	if instance == "" {
+		return 0
+	}
+
+	faultCenters, err := c.ctx.DB.FaultCenter().List(tenantId, "")
+	if err != nil {
+		logc.Errorf(c.ctx.Ctx, "获取故障中心列表失败: %s", err.Error())
+		return 0
+	}
+
+	cleared := 0
+	for _, faultCenter := range faultCenters {
+		events, err := c.ctx.Redis.Alert().GetAllEvents(models.BuildAlertEventCacheKey(tenantId, faultCenter.ID))
+		if err != nil {
+			logc.Errorf(c.ctx.Ctx, "获取故障中心 %s 告警事件失败: %s", faultCenter.ID, err.Error())
+			continue
+		}
+
+		for fingerprint, event := range events {
+			value, ok := event.Labels["instance"]
+			if !ok || fmt.Sprint(value) != instance {
+				continue
+			}
+
+			c.ctx.Redis.Alert().RemoveAlertEvent(tenantId, faultCenter.ID, fingerprint)
+			cleared++
+		}
+	}
+
+	return cleared
+}
+
+// findServiceInstance 查找指定 ServiceID 的实例
+// Agent 范围直接按 ServiceID 查询；服务目录范围按服务名查询后匹配 ServiceID，多个节点重复时取节点名最小的实例
+func findServiceInstance(client *consulclient.Client, catalogScope bool, serviceName, serviceID string) (consulclient.ServiceInstance, bool) {
+	if !catalogScope {
+		instance, err := client.GetServiceByID(context.Background(), serviceID)
+		if err != nil || instance == nil {
+			return consulclient.ServiceInstance{}, false
+		}
+		return *instance, true
+	}
+
+	instances, err := client.GetCatalogServiceInstances(context.Background(), serviceName)
+	if err != nil {
+		return consulclient.ServiceInstance{}, false
+	}
+
+	var (
+		found consulclient.ServiceInstance
+		ok    bool
+	)
+	for _, instance := range instances {
+		if instance.ServiceID == serviceID && (!ok || instance.Node < found.Node) {
+			found, ok = instance, true
+		}
+	}
+	return found, ok
+}
+
 // buildInstanceFromAddressAndPort 从 address 和 port 构建 Instance 字符串
 // 格式： "192.168.1.100:9100" 或 "192.168.1.100"（如果 port 为 0）
 func (c *consulService) buildInstanceFromAddressAndPort(address string, port int) string {
@@ -326,8 +429,8 @@ func (c *consulService) ReRegisterTarget(tenantId string, targetId int64, userId
 		return nil, fmt.Errorf("目标 ServiceID 或 ServiceName 为空，无法重新注册到 Consul")
 	}
 
-	// 从数据源系统中获取 Consul 配置
-	config, err := c.getConsulConfigFromDataSource(tenantId)
+	// 从数据源系统中获取 Consul 配置，重新注册到目标原来所在的 Consul 数据源
+	config, err := c.getConsulConfigFromDataSource(tenantId, target.DatasourceId)
 	if err != nil {
 		return nil, fmt.Errorf("获取 Consul 配置失败: %w", err)
 	}
@@ -351,11 +454,11 @@ func (c *consulService) ReRegisterTarget(tenantId string, targetId int64, userId
 		return nil, fmt.Errorf("解析实例地址失败: %w", err)
 	}
 
-	// 如果端口为 0，尝试从 Consul 获取服务的原始信息
-	// 注意：如果服务已被注销，GetServiceByID 会失败，这是正常的
+	// 如果端口为 0，尝试从 Consul 获取服务的原始信息，查找范围与同步范围一致
+	// 注意：如果服务已被注销，Consul 中不会再有该实例，这是正常的
 	if port == 0 {
-		serviceInfo, err := client.GetServiceByID(context.Background(), target.ServiceID)
-		if err != nil || serviceInfo == nil || serviceInfo.Port == 0 {
+		serviceInfo, ok := findServiceInstance(client, config.UseCatalogScope(), target.ServiceName, target.ServiceID)
+		if !ok || serviceInfo.Port == 0 {
 			return nil, fmt.Errorf("实例地址 '%s' 中缺少端口信息，且无法从 Consul 获取（服务可能已被注销）。请先同步 Consul 目标，确保 Instance 格式为 'address:port'", target.Instance)
 		}
 		port = serviceInfo.Port
@@ -373,6 +476,7 @@ func (c *consulService) ReRegisterTarget(tenantId string, targetId int64, userId
 		port,
 		tags,
 		meta,
+		target.HealthCheck, // 按注册时的健康检查配置重新注册
 	); err != nil {
 		return nil, fmt.Errorf("重新注册服务到 Consul 失败: %w", err)
 	}
@@ -381,6 +485,13 @@ func (c *consulService) ReRegisterTarget(tenantId string, targetId int64, userId
 	target.ConsulDeregistered = false
 	target.Status = "passing"       // 设置为正常状态，等待下次同步时根据实际健康检查状态更新
 	target.DeregistrationTime = nil // 清空注销时间
+	if target.HealthCheck != nil {
+		if checkStatus, err := client.GetServiceCheckStatus(context.Background(), target.ServiceID); err == nil {
+			target.Status = checkStatus
+		} else {
+			target.Status = "critical"
+		}
+	}
 
 	// 更新数据库
 	if err := c.ctx.DB.Consul().UpdateTarget(target); err != nil {
@@ -395,7 +506,7 @@ func (c *consulService) ReRegisterTarget(tenantId string, targetId int64, userId
 }
 
 // RegisterTarget 手动注册服务到 Consul
-func (c *consulService) RegisterTarget(tenantId string, serviceID, serviceName, address string, port int, job string, tags []string, labels map[string]string) (interface{}, interface{}) {
+func (c *consulService) RegisterTarget(tenantId string, serviceID, serviceName, address string, port int, job string, tags []string, labels map[string]string, check *consulclient.HealthCheckConfig, consulDatasourceId string) (interface{}, interface{}) {
 	// 验证必填字段
 	if serviceID == "" {
 		return nil, fmt.Errorf("ServiceID 不能为空")
@@ -409,6 +520,11 @@ func (c *consulService) RegisterTarget(tenantId string, serviceID, serviceName,
 	if port <= 0 || port > 65535 {
 		return nil, fmt.Errorf("服务端口必须在 1-65535 之间")
 	}
+	if check != nil {
+		if err := check.Validate(); err != nil {
+			return nil, err
+		}
+	}
 
 	// 检查数据库中是否已存在相同的 ServiceID（同一租户内）
 	existingTarget, err := c.ctx.DB.Consul().GetTargetsByInstance(tenantId, c.buildInstanceFromAddressAndPort(address, port))
@@ -420,15 +536,15 @@ func (c *consulService) RegisterTarget(tenantId string, serviceID, serviceName,
 	}
 
 	// 从数据源系统中获取 Consul 配置
-	config, err := c.getConsulConfigFromDataSource(tenantId)
+	dataSource, _, err := c.resolveConsulDatasource(tenantId, consulDatasourceId)
 	if err != nil {
 		return nil, fmt.Errorf("获取 Consul 配置失败: %w", err)
 	}
 
 	// 创建 Consul 客户端
 	consulConfig := consulclient.ClientConfig{
-		Address: config.Address,
-		Token:   config.Token,
+		Address: dataSource.ConsulConfig.Address,
+		Token:   dataSource.ConsulConfig.Token,
 	}
 	client, err := consulclient.NewClient(consulConfig)
 	if err != nil {
@@ -459,10 +575,21 @@ func (c *consulService) RegisterTarget(tenantId string, serviceID, serviceName,
 		port,
 		tags,
 		meta,
+		check,
 	); err != nil {
 		return nil, fmt.Errorf("注册服务到 Consul 失败: %w", err)
 	}
 
+	// 新注册的服务默认为 passing 状态；配置了健康检查时以 Agent 上的初始检查状态为准
+	status := "passing"
+	if check != nil {
+		if checkStatus, err := client.GetServiceCheckStatus(context.Background(), serviceID); err == nil {
+			status = checkStatus
+		} else {
+			status = "critical"
+		}
+	}
+
 	// 构建 Labels（用于数据库存储）
 	// 将 Tags 和 Meta 合并到 Labels 中
 	dbLabels := consulclient.BuildLabelsFromTagsAndMeta(tags, meta)
@@ -478,7 +605,9 @@ func (c *consulService) RegisterTarget(tenantId string, serviceID, serviceName,
 		Labels:             dbLabels,
 		ServiceID:          serviceID,
 		ServiceName:        serviceName,
-		Status:             "passing", // 新注册的服务默认为 passing 状态
+		DatasourceId:       dataSource.ID,
+		Status:             status,
+		HealthCheck:        check,
 		ConsulDeregistered: false,
 		DeregistrationTime: nil,
 		CreatedAt:          time.Now(),
@@ -501,45 +630,77 @@ func (c *consulService) RegisterTarget(tenantId string, serviceID, serviceName,
 }
 
 // SyncTargets 同步 Consul 中的目标
-func (c *consulService) SyncTargets(tenantId string) (interface{}, interface{}) {
+// consulDatasourceId 为空时同步租户的默认 Consul 数据源，只处理属于该数据源的目标
+// dryRun 为 true 时只计算变更并返回预览，不清理重复记录也不写入数据库
+func (c *consulService) SyncTargets(tenantId string, consulDatasourceId string, dryRun bool) (interface{}, interface{}) {
+	// 同一租户的同步串行执行，避免自动同步与手动同步并发时重复创建目标
+	unlock := lockConsulSync(tenantId)
+	defer unlock()
+
 	// 第一步：自动清理重复的目标记录（保留最新的那条）
-	// 这样可以修复数据库中已存在的重复记录问题
-	deletedCount, err := c.ctx.DB.Consul().CleanupDuplicateTargets(tenantId)
-	if err != nil {
-		// 记录日志但继续执行，清理失败不应该阻止同步
-		fmt.Printf("清理重复记录失败: %v\n", err)
-	} else if deletedCount > 0 {
-		fmt.Printf("同步前自动清理了 %d 条重复记录\n", deletedCount)
+	// 这样可以修复数据库中已存在的重复记录问题；预览模式只统计不删除
+	var deletedCount int64
+	if dryRun {
+		var err error
+		deletedCount, err = c.ctx.DB.Consul().CountDuplicateTargets(tenantId)
+		if err != nil {
+			logc.Errorf(c.ctx.Ctx, "统计重复记录失败: %s", err.Error())
+		}
+	} else {
+		var err error
+		deletedCount, err = c.ctx.DB.Consul().CleanupDuplicateTargets(tenantId)
+		if err != nil {
+			// 记录日志但继续执行，清理失败不应该阻止同步
+			fmt.Printf("清理重复记录失败: %v\n", err)
+		} else if deletedCount > 0 {
+			fmt.Printf("同步前自动清理了 %d 条重复记录\n", deletedCount)
+		}
 	}
 
 	// 第二步：从数据源系统中获取 Consul 配置
-	config, err := c.getConsulConfigFromDataSource(tenantId)
+	dataSource, isDefault, err := c.resolveConsulDatasource(tenantId, consulDatasourceId)
 	if err != nil {
 		return nil, err
 	}
 
 	// 创建 Consul 客户端
 	consulConfig := consulclient.ClientConfig{
-		Address: config.Address,
-		Token:   config.Token,
+		Address: dataSource.ConsulConfig.Address,
+		Token:   dataSource.ConsulConfig.Token,
 	}
 	client, err := consulclient.NewClient(consulConfig)
 	if err != nil {
 		return nil, fmt.Errorf("创建 Consul 客户端失败: %w", err)
 	}
 
-	// 获取 Consul 中的所有服务
-	consulServices, err := client.GetServices(context.Background())
+	// 批量获取所有服务的地址、端口、Tags 和 Meta，避免逐个实例查询
+	// 默认只同步所连接 Agent 上的服务，显式配置 catalog 范围时才同步集群内所有节点的服务
+	var consulServices map[string]consulclient.ServiceInstance
+	if dataSource.ConsulConfig.UseCatalogScope() {
+		consulServices, err = client.GetCatalogServiceDetails(context.Background())
+	} else {
+		consulServices, err = client.GetAllServiceDetails(context.Background())
+	}
 	if err != nil {
-		return nil, fmt.Errorf("获取 Consul 服务列表失败: %w", err)
+		return nil, err
 	}
 
-	// 获取数据库中该租户的所有现有目标
-	dbTargets, err := c.ctx.DB.Consul().GetAllTargetsByTenant(tenantId)
+	// 获取数据库中该租户的所有现有目标，只处理属于当前 Consul 数据源的目标
+	allTargets, err := c.ctx.DB.Consul().GetAllTargetsByTenant(tenantId)
 	if err != nil {
 		return nil, fmt.Errorf("获取数据库目标列表失败: %w", err)
 	}
 
+	dbTargets := make([]models.ConsulTarget, 0, len(allTargets))
+	otherServiceIDs := make(map[string]bool)
+	for _, target := range allTargets {
+		if belongsToConsulDatasource(target, dataSource.ID, isDefault) {
+			dbTargets = append(dbTargets, target)
+		} else {
+			otherServiceIDs[target.ServiceID] = true
+		}
+	}
+
 	// 构建 Map 用于快速查找
 	// 如果存在重复记录，保留最新更新的那条（通过 UpdatedAt 判断）
 	dbTargetMap := make(map[string]models.ConsulTarget)
@@ -565,6 +726,12 @@ func (c *consulService) SyncTargets(tenantId string) (interface{}, interface{})
 	for serviceID, service := range consulServices {
 		consulServiceMap[serviceID] = true
 
+		// ServiceID 在租户内唯一，已由其他 Consul 数据源管理的服务不重复创建
+		if _, exists := dbTargetMap[serviceID]; !exists && otherServiceIDs[serviceID] {
+			logc.Infof(c.ctx.Ctx, "服务 %s 已属于其他 Consul 数据源，跳过同步", serviceID)
+			continue
+		}
+
 		// 构建 Labels（包含 Tags 和 Meta），使用 pkg 层的辅助函数
 		labels := consulclient.BuildLabelsFromTagsAndMeta(service.Tags, service.Meta)
 
@@ -577,6 +744,11 @@ func (c *consulService) SyncTargets(tenantId string) (interface{}, interface{})
 			// 服务已存在，检查是否需要更新
 			// 需要更新的情况：实例地址变化、状态为 "no checks"、或 Tags/Meta 变化
 			needUpdate := false
+			// 历史目标补充所属数据源
+			if dbTarget.DatasourceId == "" {
+				dbTarget.DatasourceId = dataSource.ID
+				needUpdate = true
+			}
 			// 构建包含端口的 Instance 字符串
 			expectedInstance := c.buildInstanceFromAddressAndPort(service.Address, service.Port)
 			if dbTarget.Instance != expectedInstance {
@@ -601,13 +773,14 @@ func (c *consulService) SyncTargets(tenantId string) (interface{}, interface{})
 			// 构建包含端口的 Instance 字符串
 			instance := c.buildInstanceFromAddressAndPort(service.Address, service.Port)
 			newTarget := models.ConsulTarget{
-				TenantId:    tenantId,
-				Instance:    instance,
-				Job:         service.Service,
-				ServiceID:   serviceID,
-				ServiceName: service.Service,
-				Status:      "passing",
-				Labels:      labels, // 保存 Tags 和 Meta
+				TenantId:     tenantId,
+				Instance:     instance,
+				Job:          service.ServiceName,
+				ServiceID:    serviceID,
+				ServiceName:  service.ServiceName,
+				DatasourceId: dataSource.ID,
+				Status:       "passing",
+				Labels:       labels, // 保存 Tags 和 Meta
 			}
 			toCreate = append(toCreate, newTarget)
 		}
@@ -617,6 +790,7 @@ func (c *consulService) SyncTargets(tenantId string) (interface{}, interface{})
 	// 注意：已手动注销的目标（DeregistrationTime != nil）不应被自动删除逻辑影响
 	// 通过 DeregistrationTime 是否为 nil 来区分手动注销和自动删除
 	toDeleteServiceIDs := make([]string, 0)
+	toDeleteInstances := make([]string, 0)
 	for _, dbTarget := range dbTargets {
 		// 只处理以下条件的目标：
 		// 1. Consul 中不存在该服务
@@ -629,9 +803,25 @@ func (c *consulService) SyncTargets(tenantId string) (interface{}, interface{})
 			!dbTarget.ConsulDeregistered { // 当前不是已注销状态
 			// 服务已从 Consul 中删除，需要标记为无检查状态（自动删除）
 			toDeleteServiceIDs = append(toDeleteServiceIDs, dbTarget.ServiceID)
+			toDeleteInstances = append(toDeleteInstances, dbTarget.Instance)
 		}
 	}
 
+	// 受影响的实例列表，便于前端展示同步预览
+	newInstances := make([]string, 0, len(toCreate))
+	for _, target := range toCreate {
+		newInstances = append(newInstances, target.Instance)
+	}
+	updatedInstances := make([]string, 0, len(toUpdate))
+	for _, target := range toUpdate {
+		updatedInstances = append(updatedInstances, target.Instance)
+	}
+
+	// 预览模式不执行任何数据库操作
+	if dryRun {
+		return c.buildSyncTargetsResult(true, deletedCount, newInstances, updatedInstances, toDeleteInstances, len(consulServices)), nil
+	}
+
 	// 批量执行数据库操作
 	// 1. 批量创建新目标
 	if len(toCreate) > 0 {
@@ -654,19 +844,23 @@ func (c *consulService) SyncTargets(tenantId string) (interface{}, interface{})
 		}
 	}
 
-	// 统计操作结果
-	newTargetsCount := len(toCreate)
-	updatedTargetsCount := len(toUpdate)
-	deletedTargetsCount := len(toDeleteServiceIDs)
+	return c.buildSyncTargetsResult(false, deletedCount, newInstances, updatedInstances, toDeleteInstances, len(consulServices)), nil
+}
 
+// buildSyncTargetsResult 构建同步结果，预览模式与实际同步返回相同结构
+func (c *consulService) buildSyncTargetsResult(dryRun bool, cleanedCount int64, newInstances, updatedInstances, deletedInstances []string, total int) map[string]interface{} {
 	return map[string]interface{}{
 		"syncTime":              time.Now(),
-		"cleanedDuplicateCount": deletedCount,        // 清理的重复记录数
-		"newTargetsCount":       newTargetsCount,     // 新创建的记录数
-		"updatedTargetsCount":   updatedTargetsCount, // 更新的记录数
-		"deletedTargetsCount":   deletedTargetsCount, // 标记删除的记录数
-		"totalTargetsCount":     len(consulServices), // Consul 中的服务总数
-	}, nil
+		"dryRun":                dryRun,                // 是否为预览模式
+		"cleanedDuplicateCount": cleanedCount,          // 清理的重复记录数（预览模式为待清理数）
+		"newTargetsCount":       len(newInstances),     // 新创建的记录数
+		"updatedTargetsCount":   len(updatedInstances), // 更新的记录数
+		"deletedTargetsCount":   len(deletedInstances), // 标记删除的记录数
+		"totalTargetsCount":     total,                 // Consul 中的服务总数
+		"newInstances":          newInstances,          // 新创建的实例
+		"updatedInstances":      updatedInstances,      // 更新的实例
+		"deletedInstances":      deletedInstances,      // 标记删除的实例
+	}
 }
 
 // GetTargetsByTag 按标签获取目标列表
@@ -765,7 +959,7 @@ func (c *consulService) UpdateTargetTags(tenantId string, targetId int64, labels
 
 	// 同步更新到 Consul（如果服务未注销且 ServiceID 存在）
 	if !target.ConsulDeregistered && target.ServiceID != "" {
-		c.syncTagsToConsul(tenantId, target.ServiceID, labels)
+		c.syncTagsToConsul(tenantId, target.DatasourceId, target.ServiceID, labels)
 	}
 
 	return map[string]interface{}{
@@ -778,9 +972,9 @@ func (c *consulService) UpdateTargetTags(tenantId string, targetId int64, labels
 }
 
 // syncTagsToConsul 将标签同步到 Consul（不返回错误，只记录警告）
-func (c *consulService) syncTagsToConsul(tenantId, serviceID string, labels map[string]interface{}) {
-	// 获取 Consul 配置
-	config, err := c.getConsulConfigFromDataSource(tenantId)
+func (c *consulService) syncTagsToConsul(tenantId, datasourceId, serviceID string, labels map[string]interface{}) {
+	// 获取目标所在 Consul 数据源的配置
+	config, err := c.getConsulConfigFromDataSource(tenantId, datasourceId)
 	if err != nil {
 		logc.Errorf(context.Background(), "无法获取 Consul 配置，标签已更新到数据库但未同步到 Consul: %v", err)
 		return
@@ -874,3 +1068,59 @@ func (c *consulService) GetOfflineLogs(tenantId string, page, pageSize int) (int
 		"list":     logList,
 	}, nil
 }
+
+// consulSyncLocks 按租户保存的同步锁
+// ServiceID 在租户内唯一且重复记录清理按租户执行，因此按租户而不是按数据源加锁
+var consulSyncLocks sync.Map
+
+// lockConsulSync 获取租户的同步锁，返回解锁函数
+func lockConsulSync(tenantId string) func() {
+	v, _ := consulSyncLocks.LoadOrStore(tenantId, &sync.Mutex{})
+	mu := v.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// consulTargetWatchers 按 Consul 数据源ID保存的目录监听取消函数
+var consulTargetWatchers sync.Map
+
+// WatchTargets 监听 Consul 数据源的服务目录，变化时自动同步目标
+// 重复调用会先停止旧的监听；数据源未启用或未开启自动同步时只停止监听
+// 多副本部署时只有 Leader 节点执行同步
+func (c *consulService) WatchTargets(datasource models.AlertDataSource) {
+	c.StopWatchTargets(datasource.ID)
+
+	if datasource.Type != "consul" || !*datasource.GetEnabled() ||
+		!datasource.ConsulConfig.EnableWatch || datasource.ConsulConfig.Address == "" {
+		return
+	}
+
+	client, err := consulclient.NewClient(consulclient.ClientConfig{
+		Address: datasource.ConsulConfig.Address,
+		Token:   datasource.ConsulConfig.Token,
+	})
+	if err != nil {
+		logc.Errorf(c.ctx.Ctx, "创建 Consul 客户端失败，无法监听数据源 %s: %v", datasource.ID, err)
+		return
+	}
+
+	watchCtx, cancel := context.WithCancel(context.Background())
+	consulTargetWatchers.Store(datasource.ID, cancel)
+
+	go client.WatchCatalog(watchCtx, func() {
+		if !alert.IsLeader() {
+			return
+		}
+		if _, err := c.SyncTargets(datasource.TenantId, datasource.ID, false); err != nil {
+			logc.Errorf(c.ctx.Ctx, "Consul 服务目录变更，自动同步数据源 %s 失败: %v", datasource.ID, err)
+		}
+	})
+	logc.Infof(c.ctx.Ctx, "已开启 Consul 数据源 %s 的服务目录监听", datasource.ID)
+}
+
+// StopWatchTargets 停止 Consul 数据源的服务目录监听
+func (c *consulService) StopWatchTargets(datasourceId string) {
+	if cancel, ok := consulTargetWatchers.LoadAndDelete(datasourceId); ok {
+		cancel.(context.CancelFunc)()
+	}
+}
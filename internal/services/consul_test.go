@@ -0,0 +1,42 @@
+package services
+
+import (
+	"alertHub/internal/ctx"
+	"alertHub/internal/models"
+	"context"
+	"testing"
+)
+
+func TestClearAlertEventsByInstance(t *testing.T) {
+	alertCache := newFakeAlertCache()
+	faultCenters := &fakeFaultCenterRepo{data: []models.FaultCenter{
+		{TenantId: "t-1", ID: "fc-1"},
+		{TenantId: "t-1", ID: "fc-2"},
+	}}
+	c := &consulService{ctx: &ctx.Context{
+		Ctx:   context.Background(),
+		DB:    &fakeEntryRepo{faultCenter: faultCenters},
+		Redis: &fakeEntryCache{alert: alertCache},
+	}}
+
+	events := []models.AlertCurEvent{
+		{TenantId: "t-1", FaultCenterId: "fc-1", Fingerprint: "fp-1", EventId: "e-1", Labels: map[string]interface{}{"instance": "10.0.0.1:9100"}},
+		{TenantId: "t-1", FaultCenterId: "fc-2", Fingerprint: "fp-2", EventId: "e-2", Labels: map[string]interface{}{"instance": "10.0.0.1:9100"}},
+		{TenantId: "t-1", FaultCenterId: "fc-1", Fingerprint: "fp-3", EventId: "e-3", Labels: map[string]interface{}{"instance": "10.0.0.2:9100"}},
+	}
+	for i := range events {
+		alertCache.PushAlertEvent(&events[i])
+	}
+
+	if cleared := c.clearAlertEventsByInstance("t-1", "10.0.0.1:9100"); cleared != 2 {
+		t.Fatalf("cleared = %d, want 2", cleared)
+	}
+
+	for _, event := range events {
+		_, err := alertCache.GetEventFromCache(event.TenantId, event.FaultCenterId, event.Fingerprint)
+		remaining := err == nil
+		if wantRemaining := event.EventId == "e-3"; remaining != wantRemaining {
+			t.Fatalf("事件 %s 保留 = %v, want %v", event.EventId, remaining, wantRemaining)
+		}
+	}
+}
@@ -7,9 +7,21 @@ import (
 	"alertHub/pkg/provider"
 	"alertHub/pkg/tools"
 	"fmt"
+	"sync"
 	"time"
 )
 
+// datasourceHealthCacheTTL 批量健康检查结果的缓存时间，避免状态页频繁刷新打满数据源
+const datasourceHealthCacheTTL = 30 * time.Second
+
+type datasourceHealthCacheEntry struct {
+	results  []models.DatasourceHealthResult
+	expireAt time.Time
+}
+
+// datasourceHealthCache 按 租户 + 数据源类型 缓存批量健康检查结果
+var datasourceHealthCache sync.Map
+
 type datasourceService struct {
 	ctx *ctx.Context
 }
@@ -22,6 +34,7 @@ type InterDatasourceService interface {
 	Get(req interface{}) (interface{}, interface{})
 	WithAddClientToProviderPools(datasource models.AlertDataSource) error
 	WithRemoveClientForProviderPools(datasourceId string)
+	HealthCheck(req interface{}) (interface{}, interface{})
 }
 
 func newInterDatasourceService(ctx *ctx.Context) InterDatasourceService {
@@ -49,6 +62,12 @@ func normalizeConsulConfig(config models.DsConsulConfig) models.DsConsulConfig {
 
 func (ds datasourceService) Create(req interface{}) (interface{}, interface{}) {
 	dataSource := req.(*types.RequestDatasourceCreate)
+	if err := dataSource.HTTP.ValidateQueryTimeout(); err != nil {
+		return nil, err
+	}
+	if err := dataSource.ConsulConfig.ValidateSyncScope(); err != nil {
+		return nil, err
+	}
 
 	// 标准化 Consul 配置
 	consulConfig := normalizeConsulConfig(dataSource.ConsulConfig)
@@ -81,12 +100,19 @@ func (ds datasourceService) Create(req interface{}) (interface{}, interface{}) {
 	if err != nil {
 		return nil, err
 	}
+	ConsulService.WatchTargets(data)
 
 	return nil, nil
 }
 
 func (ds datasourceService) Update(req interface{}) (interface{}, interface{}) {
 	dataSource := req.(*types.RequestDatasourceUpdate)
+	if err := dataSource.HTTP.ValidateQueryTimeout(); err != nil {
+		return nil, err
+	}
+	if err := dataSource.ConsulConfig.ValidateSyncScope(); err != nil {
+		return nil, err
+	}
 
 	// 标准化 Consul 配置
 	consulConfig := normalizeConsulConfig(dataSource.ConsulConfig)
@@ -119,6 +145,11 @@ func (ds datasourceService) Update(req interface{}) (interface{}, interface{}) {
 	if err != nil {
 		return nil, err
 	}
+	ConsulService.WatchTargets(data)
+
+	// 数据源地址可能变更，丢弃旧的连接池与 label 值缓存
+	provider.RemoveDatasourceHTTPClient(data.ID)
+	provider.RemoveLabelValuesCache(data.ID)
 
 	return nil, nil
 }
@@ -197,4 +228,59 @@ func (ds datasourceService) WithAddClientToProviderPools(datasource models.Alert
 func (ds datasourceService) WithRemoveClientForProviderPools(datasourceId string) {
 	pools := ds.ctx.Redis.ProviderPools()
 	pools.RemoveClient(datasourceId)
+	provider.RemoveDatasourceHTTPClient(datasourceId)
+	provider.RemoveLabelValuesCache(datasourceId)
+	ConsulService.StopWatchTargets(datasourceId)
+}
+
+// HealthCheck 并发检查租户下所有启用数据源的可达性，结果短暂缓存
+func (ds datasourceService) HealthCheck(req interface{}) (interface{}, interface{}) {
+	r := req.(*types.RequestDatasourceHealth)
+
+	// 结果按类型过滤，缓存需区分类型，避免返回其他类型的检查结果
+	cacheKey := r.TenantId + ":" + r.Type
+	if !r.Refresh {
+		if v, ok := datasourceHealthCache.Load(cacheKey); ok {
+			entry := v.(datasourceHealthCacheEntry)
+			if time.Now().Before(entry.expireAt) {
+				return buildDatasourceHealthResponse(entry.results), nil
+			}
+		}
+	}
+
+	list, err := ds.ctx.DB.Datasource().List(r.TenantId, "", r.Type, "")
+	if err != nil {
+		return nil, err
+	}
+
+	enabled := make([]models.AlertDataSource, 0, len(list))
+	for _, d := range list {
+		if *d.GetEnabled() {
+			enabled = append(enabled, d)
+		}
+	}
+
+	results := provider.CheckDatasourcesHealth(enabled, 0)
+	datasourceHealthCache.Store(cacheKey, datasourceHealthCacheEntry{
+		results:  results,
+		expireAt: time.Now().Add(datasourceHealthCacheTTL),
+	})
+
+	return buildDatasourceHealthResponse(results), nil
+}
+
+// buildDatasourceHealthResponse 汇总批量健康检查结果
+func buildDatasourceHealthResponse(results []models.DatasourceHealthResult) types.ResponseDatasourceHealth {
+	resp := types.ResponseDatasourceHealth{
+		Total:   len(results),
+		Results: results,
+	}
+	for _, result := range results {
+		if result.Reachable {
+			resp.Healthy++
+		} else {
+			resp.Unhealthy++
+		}
+	}
+	return resp
 }
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"alertHub/internal/ctx"
+	"alertHub/internal/models"
+	"alertHub/internal/types"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthCheckCacheKeyedByType(t *testing.T) {
+	// 缓存为包级变量，清理后测试可重复执行
+	for _, typ := range []string{"Prometheus", "Loki"} {
+		datasourceHealthCache.Delete("t-health:" + typ)
+	}
+
+	disabled := false
+	dsRepo := &fakeDatasourceRepo{
+		data: []models.AlertDataSource{
+			{TenantId: "t-health", ID: "prom-1", Type: "Prometheus", Enabled: &disabled},
+			{TenantId: "t-health", ID: "loki-1", Type: "Loki", Enabled: &disabled},
+		},
+	}
+	ds := datasourceService{ctx: &ctx.Context{Ctx: context.Background(), DB: &fakeEntryRepo{datasource: dsRepo}}}
+
+	for _, typ := range []string{"Prometheus", "Loki", "Prometheus", "Loki"} {
+		if _, err := ds.HealthCheck(&types.RequestDatasourceHealth{TenantId: "t-health", Type: typ}); err != nil {
+			t.Fatalf("HealthCheck(%s) err: %v", typ, err)
+		}
+	}
+
+	// 不同类型各查询一次，同类型的第二次请求命中缓存
+	want := []string{"Prometheus", "Loki"}
+	if len(dsRepo.listTypes) != len(want) {
+		t.Fatalf("List 调用 %v, want %v", dsRepo.listTypes, want)
+	}
+	for i := range want {
+		if dsRepo.listTypes[i] != want[i] {
+			t.Fatalf("List 调用 %v, want %v", dsRepo.listTypes, want)
+		}
+	}
+}
+
+func TestHealthCheckAggregatesMixedDatasources(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"yaml":""}}`))
+	}))
+	defer healthy.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	enabled, disabled := true, false
+	prom := func(id, url string, on *bool) models.AlertDataSource {
+		return models.AlertDataSource{TenantId: "t-mixed", ID: id, Name: id, Type: "Prometheus", HTTP: models.HTTP{URL: url}, Enabled: on}
+	}
+	dsRepo := &fakeDatasourceRepo{
+		data: []models.AlertDataSource{
+			prom("prom-up-1", healthy.URL, &enabled),
+			prom("prom-down", down.URL, &enabled),
+			prom("prom-off", down.URL, &disabled),
+			prom("prom-up-2", healthy.URL, &enabled),
+		},
+	}
+	ds := datasourceService{ctx: &ctx.Context{Ctx: context.Background(), DB: &fakeEntryRepo{datasource: dsRepo}}}
+
+	res, err := ds.HealthCheck(&types.RequestDatasourceHealth{TenantId: "t-mixed", Refresh: true})
+	if err != nil {
+		t.Fatalf("HealthCheck err: %v", err)
+	}
+	resp := res.(types.ResponseDatasourceHealth)
+
+	if resp.Total != 3 || resp.Healthy != 2 || resp.Unhealthy != 1 {
+		t.Fatalf("got total=%d healthy=%d unhealthy=%d, want 3/2/1 (disabled datasource skipped)", resp.Total, resp.Healthy, resp.Unhealthy)
+	}
+	want := []struct {
+		id        string
+		reachable bool
+	}{{"prom-up-1", true}, {"prom-down", false}, {"prom-up-2", true}}
+	for i, w := range want {
+		got := resp.Results[i]
+		if got.DatasourceId != w.id || got.Reachable != w.reachable {
+			t.Fatalf("result %d got %s reachable=%v, want %s reachable=%v", i, got.DatasourceId, got.Reachable, w.id, w.reachable)
+		}
+	}
+	if resp.Results[1].Error == "" {
+		t.Fatal("unreachable datasource must report its error")
+	}
+}
@@ -39,7 +39,7 @@ func (dms dutyCalendarService) CreateAndUpdate(req interface{}) (interface{}, in
 	}
 
 	if err := dms.updateDutyScheduleInDB(dutyScheduleList, r.TenantId); err != nil {
-		logc.Errorf(dms.ctx.Ctx, err.Error())
+		logc.Error(dms.ctx.Ctx, err.Error())
 	}
 	return nil, nil
 }
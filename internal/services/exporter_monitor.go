@@ -138,6 +138,13 @@ func (s *exporterMonitorService) GetConfig(tenantId string) (models.ExporterMoni
 
 // SaveConfig 保存 Exporter 监控配置 (纯 DB 操作)
 func (s *exporterMonitorService) SaveConfig(config models.ExporterMonitorConfig) error {
+	if config.GoodAvailability < 0 || config.GoodAvailability > 100 || config.WarnAvailability < 0 || config.WarnAvailability > 100 {
+		return fmt.Errorf("可用率阈值必须在 0-100 之间")
+	}
+	if config.GoodAvailability > 0 && config.WarnAvailability > config.GoodAvailability {
+		return fmt.Errorf("可用率告警阈值不能大于良好阈值")
+	}
+
 	return s.ctx.DB.ExporterMonitor().SaveConfig(config)
 }
 
@@ -191,7 +198,7 @@ func (s *exporterMonitorService) SendReport(tenantId string, noticeGroups []stri
 	}
 
 	// 3. 生成报告内容 (委托给 Reporter)
-	reporter := exporter.NewReporter()
+	reporter := exporter.NewReporter(exporter.LoadAvailabilityThresholds(s.ctx, tenantId))
 	content := reporter.GenerateReportContent(summary, exporters, historyData, reportFormat)
 
 	// 4. 调用 Notifier 推送通知
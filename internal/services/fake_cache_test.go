@@ -0,0 +1,103 @@
+package services
+
+import (
+	"alertHub/internal/cache"
+	"alertHub/internal/models"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// fakeEntryCache 测试用的缓存入口，只实现测试用到的子缓存
+type fakeEntryCache struct {
+	cache.InterEntryCache
+	alert *fakeAlertCache
+}
+
+func (f *fakeEntryCache) Alert() cache.AlertCacheInterface { return f.alert }
+
+// fakeAlertCache 基于内存的告警事件缓存，指纹索引语义与 Redis 实现一致
+type fakeAlertCache struct {
+	cache.AlertCacheInterface
+	mu                sync.Mutex
+	events            map[models.AlertEventCacheKey]map[string]models.AlertCurEvent
+	index             map[string]map[string]string // key: 租户 + 指纹，field: 故障中心ID，value: 事件ID
+	getAllEventsCalls int
+}
+
+func newFakeAlertCache() *fakeAlertCache {
+	return &fakeAlertCache{
+		events: make(map[models.AlertEventCacheKey]map[string]models.AlertCurEvent),
+		index:  make(map[string]map[string]string),
+	}
+}
+
+// putEvent 只写入故障中心事件缓存，不维护指纹索引，模拟绕过 PushAlertEvent 的写入
+func (f *fakeAlertCache) putEvent(event models.AlertCurEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := models.BuildAlertEventCacheKey(event.TenantId, event.FaultCenterId)
+	if f.events[key] == nil {
+		f.events[key] = make(map[string]models.AlertCurEvent)
+	}
+	f.events[key][event.Fingerprint] = event
+}
+
+func (f *fakeAlertCache) PushAlertEvent(event *models.AlertCurEvent) {
+	f.putEvent(*event)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	indexKey := fmt.Sprintf("%s|%s", event.TenantId, event.Fingerprint)
+	if f.index[indexKey] == nil {
+		f.index[indexKey] = make(map[string]string)
+	}
+	f.index[indexKey][event.FaultCenterId] = event.EventId
+}
+
+func (f *fakeAlertCache) RemoveAlertEvent(tenantId, faultCenterId, fingerprint string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.events[models.BuildAlertEventCacheKey(tenantId, faultCenterId)], fingerprint)
+	delete(f.index[fmt.Sprintf("%s|%s", tenantId, fingerprint)], faultCenterId)
+}
+
+func (f *fakeAlertCache) GetAllEvents(key models.AlertEventCacheKey) (map[string]*models.AlertCurEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.getAllEventsCalls++
+
+	events := make(map[string]*models.AlertCurEvent)
+	for fingerprint, event := range f.events[key] {
+		event := event
+		events[fingerprint] = &event
+	}
+	return events, nil
+}
+
+func (f *fakeAlertCache) GetEventFromCache(tenantId, faultCenterId, fingerprint string) (models.AlertCurEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	event, ok := f.events[models.BuildAlertEventCacheKey(tenantId, faultCenterId)][fingerprint]
+	if !ok {
+		return models.AlertCurEvent{}, fmt.Errorf("事件不存在")
+	}
+	return event, nil
+}
+
+func (f *fakeAlertCache) GetFingerprintIndex(tenantId, fingerprint string) ([]models.AlertFingerprintIndex, error) {
+	f.mu.Lock()
+	entries := f.index[fmt.Sprintf("%s|%s", tenantId, fingerprint)]
+	f.mu.Unlock()
+
+	var indexes []models.AlertFingerprintIndex
+	for faultCenterId, eventId := range entries {
+		event, err := f.GetEventFromCache(tenantId, faultCenterId, fingerprint)
+		if err != nil || event.EventId != eventId {
+			continue
+		}
+		indexes = append(indexes, models.AlertFingerprintIndex{FaultCenterId: faultCenterId, EventId: eventId})
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].FaultCenterId < indexes[j].FaultCenterId })
+	return indexes, nil
+}
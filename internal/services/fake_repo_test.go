@@ -0,0 +1,58 @@
+package services
+
+import (
+	"alertHub/internal/models"
+	"alertHub/internal/repo"
+)
+
+// fakeEntryRepo 测试用的仓储入口，只实现测试用到的子仓储，其余方法调用会 panic
+type fakeEntryRepo struct {
+	repo.InterEntryRepo
+	datasource  *fakeDatasourceRepo
+	faultCenter *fakeFaultCenterRepo
+}
+
+func (f *fakeEntryRepo) Datasource() repo.InterDatasourceRepo   { return f.datasource }
+func (f *fakeEntryRepo) FaultCenter() repo.InterFaultCenterRepo { return f.faultCenter }
+
+// fakeDatasourceRepo 基于内存的数据源仓储，记录 List 的调用参数
+type fakeDatasourceRepo struct {
+	repo.InterDatasourceRepo
+	data      []models.AlertDataSource
+	listTypes []string
+}
+
+func (f *fakeDatasourceRepo) List(tenantId, datasourceId, datasourceType, query string) ([]models.AlertDataSource, error) {
+	f.listTypes = append(f.listTypes, datasourceType)
+
+	var list []models.AlertDataSource
+	for _, d := range f.data {
+		if tenantId != "" && d.TenantId != tenantId {
+			continue
+		}
+		if datasourceId != "" && d.ID != datasourceId {
+			continue
+		}
+		if datasourceType != "" && d.Type != datasourceType {
+			continue
+		}
+		list = append(list, d)
+	}
+	return list, nil
+}
+
+// fakeFaultCenterRepo 基于内存的故障中心仓储
+type fakeFaultCenterRepo struct {
+	repo.InterFaultCenterRepo
+	data []models.FaultCenter
+}
+
+func (f *fakeFaultCenterRepo) List(tenantId, query string) ([]models.FaultCenter, error) {
+	var list []models.FaultCenter
+	for _, fc := range f.data {
+		if fc.TenantId == tenantId {
+			list = append(list, fc)
+		}
+	}
+	return list, nil
+}
@@ -40,13 +40,13 @@ func newInterLdapService(ctx *ctx.Context) InterLdapService {
 func (l ldapService) getAdminAuth() (*ldap.Conn, error) {
 	ls, err := ldap.Dial("tcp", l.ldapConfig.Address)
 	if err != nil {
-		logc.Errorf(l.ctx.Ctx, fmt.Sprintf("无法连接 LDAP 服务器, Address: %s, err: %s", l.ldapConfig.Address, err.Error()))
+		logc.Errorf(l.ctx.Ctx, "无法连接 LDAP 服务器, Address: %s, err: %s", l.ldapConfig.Address, err.Error())
 		return nil, err
 	}
 
 	err = ls.Bind(l.ldapConfig.AdminUser, l.ldapConfig.AdminPass)
 	if err != nil {
-		logc.Errorf(l.ctx.Ctx, fmt.Sprintf("LDAP 管理员绑定失败 err: %s", err.Error()))
+		logc.Errorf(l.ctx.Ctx, "LDAP 管理员绑定失败 err: %s", err.Error())
 		return nil, err
 	}
 
@@ -89,7 +89,7 @@ func (l ldapService) ListUsers() ([]ldapUser, error) {
 
 		searchResult, err := auth.Search(searchRequest)
 		if err != nil {
-			logc.Errorf(l.ctx.Ctx, fmt.Sprintf("第 %d 页查询失败: %s", pages, err.Error()))
+			logc.Errorf(l.ctx.Ctx, "第 %d 页查询失败: %s", pages, err.Error())
 			return nil, err
 		}
 
@@ -140,7 +140,7 @@ func (l ldapService) ListUsers() ([]ldapUser, error) {
 func (l ldapService) SyncUserToW8t() {
 	users, err := l.ListUsers()
 	if err != nil {
-		logc.Errorf(l.ctx.Ctx, err.Error())
+		logc.Error(l.ctx.Ctx, err.Error())
 		return
 	}
 
@@ -161,7 +161,7 @@ func (l ldapService) SyncUserToW8t() {
 		}
 		err = l.ctx.DB.User().Create(m)
 		if err != nil {
-			logc.Errorf(l.ctx.Ctx, err.Error())
+			logc.Error(l.ctx.Ctx, err.Error())
 			return
 		}
 
@@ -175,7 +175,7 @@ func (l ldapService) SyncUserToW8t() {
 			l.ldapConfig.DefaultUserRole,
 		)
 		if err != nil {
-			logc.Errorf(l.ctx.Ctx, err.Error())
+			logc.Error(l.ctx.Ctx, err.Error())
 			return
 		}
 	}
@@ -184,7 +184,7 @@ func (l ldapService) SyncUserToW8t() {
 func (l ldapService) Login(username, password string) error {
 	auth, err := l.getAdminAuth()
 	if err != nil {
-		logc.Errorf(l.ctx.Ctx, err.Error())
+		logc.Error(l.ctx.Ctx, err.Error())
 		return err
 	}
 	defer auth.Close()
@@ -202,12 +202,12 @@ func (l ldapService) Login(username, password string) error {
 
 	searchResult, err := auth.Search(searchRequest)
 	if err != nil {
-		logc.Errorf(l.ctx.Ctx, fmt.Sprintf("LDAP 搜索用户失败, username: %s, err: %s", username, err.Error()))
+		logc.Errorf(l.ctx.Ctx, "LDAP 搜索用户失败, username: %s, err: %s", username, err.Error())
 		return err
 	}
 
 	if len(searchResult.Entries) == 0 {
-		logc.Errorf(l.ctx.Ctx, fmt.Sprintf("LDAP 用户不存在, username: %s", username))
+		logc.Errorf(l.ctx.Ctx, "LDAP 用户不存在, username: %s", username)
 		return fmt.Errorf("用户不存在")
 	}
 
@@ -216,7 +216,7 @@ func (l ldapService) Login(username, password string) error {
 
 	err = auth.Bind(userDN, password)
 	if err != nil {
-		logc.Errorf(l.ctx.Ctx, fmt.Sprintf("LDAP 用户登陆失败, username: %s, DN: %s, err: %s", username, userDN, err.Error()))
+		logc.Errorf(l.ctx.Ctx, "LDAP 用户登陆失败, username: %s, DN: %s, err: %s", username, userDN, err.Error())
 		return err
 	}
 
@@ -231,7 +231,7 @@ func (l ldapService) SyncUsersCronjob(ctx context.Context) {
 		l.SyncUserToW8t()
 	})
 	if err != nil {
-		logc.Errorf(ctx, err.Error())
+		logc.Error(ctx, err.Error())
 		return
 	}
 	c.Start()
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"alertHub/config"
 	"alertHub/internal/ctx"
 	"alertHub/internal/models"
 	"alertHub/internal/repo"
@@ -8,14 +9,18 @@ import (
 	"alertHub/pkg/tools"
 	"errors"
 	"fmt"
+	"math"
+	"sync/atomic"
 	"time"
 
+	"github.com/zeromicro/go-zero/core/logc"
+	"golang.org/x/sync/errgroup"
 	"gorm.io/gorm"
 )
 
 type (
+	// processTraceService 持久化全部经由 repo 接口完成，便于替换存储实现
 	processTraceService struct {
-		db      *gorm.DB
 		ctx     *ctx.Context
 		repo    repo.ProcessTraceRepo
 		logRepo repo.ProcessOperationLogRepo
@@ -52,42 +57,125 @@ type (
 
 		// 获取流程统计数据
 		GetProcessStatistics(tenantId string, startTime, endTime int64) (map[string]interface{}, error)
+
+		// 汇总并清理超过保留期的操作日志
+		RollupOperationLogs(cfg config.ProcessOperationLogRetention) error
 	}
 )
 
 func NewInterProcessTraceService(ctx *ctx.Context) InterProcessTraceService {
+	return NewInterProcessTraceServiceWithRepo(ctx,
+		repo.NewProcessTraceRepo(ctx.DB.DB()),
+		repo.NewProcessOperationLogRepo(ctx.DB.DB()),
+	)
+}
+
+// NewInterProcessTraceServiceWithRepo 使用指定的 repo 实现创建服务
+func NewInterProcessTraceServiceWithRepo(ctx *ctx.Context, traceRepo repo.ProcessTraceRepo, logRepo repo.ProcessOperationLogRepo) InterProcessTraceService {
 	return &processTraceService{
-		db:      ctx.DB.DB(),
 		ctx:     ctx,
-		repo:    repo.NewProcessTraceRepo(ctx.DB.DB()),
-		logRepo: repo.NewProcessOperationLogRepo(ctx.DB.DB()),
+		repo:    traceRepo,
+		logRepo: logRepo,
 	}
 }
 
 // getFaultCenters 获取租户下的所有故障中心
 func (pts *processTraceService) getFaultCenters(tenantId string) ([]models.FaultCenter, error) {
-	var faultCenters []models.FaultCenter
-	err := pts.db.Where("tenant_id = ?", tenantId).Find(&faultCenters).Error
-	return faultCenters, err
+	return pts.ctx.DB.FaultCenter().List(tenantId, "")
+}
+
+// faultCenterLookupConcurrency 并发查询故障中心缓存的上限
+const faultCenterLookupConcurrency = 8
+
+// lookupFaultCenters 并发在多个故障中心中查找，返回按故障中心顺序第一个命中的结果
+// 与串行遍历结果一致；单个故障中心查询失败不影响其他故障中心，均未命中时返回汇总的错误
+func lookupFaultCenters[T any](faultCenters []models.FaultCenter, lookup func(fc models.FaultCenter) (T, bool, error)) (T, bool, error) {
+	type outcome struct {
+		value T
+		ok    bool
+		err   error
+	}
+
+	var (
+		outcomes = make([]outcome, len(faultCenters))
+		// 已命中的最靠前故障中心下标，之后的故障中心无需再查询
+		firstHit atomic.Int64
+	)
+	firstHit.Store(int64(len(faultCenters)))
+
+	g := new(errgroup.Group)
+	g.SetLimit(faultCenterLookupConcurrency)
+	for i, fc := range faultCenters {
+		if int64(i) > firstHit.Load() {
+			break
+		}
+
+		g.Go(func() error {
+			if int64(i) > firstHit.Load() {
+				return nil
+			}
+			v, ok, err := lookup(fc)
+			outcomes[i] = outcome{value: v, ok: ok, err: err}
+			for ok {
+				cur := firstHit.Load()
+				if int64(i) >= cur || firstHit.CompareAndSwap(cur, int64(i)) {
+					break
+				}
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	var errs []error
+	for _, o := range outcomes {
+		if o.ok {
+			return o.value, true, nil
+		}
+		if o.err != nil {
+			errs = append(errs, o.err)
+		}
+	}
+
+	var zero T
+	return zero, false, errors.Join(errs...)
+}
+
+// cachedEventMatch Redis 缓存中匹配到的事件信息
+type cachedEventMatch struct {
+	eventId  string
+	ruleId   string
+	ruleName string
 }
 
 // resolveEventIdFromFingerprint 将指纹转换为事件ID，使用多种回退方法
 func (pts *processTraceService) resolveEventIdFromFingerprint(tenantId, fingerprint string) (string, error) {
+	// 方法0: 指纹索引直接命中，索引已与故障中心事件缓存核对
+	indexes, _ := pts.ctx.Redis.Alert().GetFingerprintIndex(tenantId, fingerprint)
+	for _, index := range indexes {
+		if index.EventId != "" && index.EventId != fingerprint {
+			return index.EventId, nil
+		}
+	}
+
 	// 方法1: 从Redis缓存中查找fingerprint对应的eventId
 	faultCenters, err := pts.getFaultCenters(tenantId)
 	if err == nil {
-		for _, fc := range faultCenters {
-			// 尝试从缓存中获取事件
+		eventId, found, _ := lookupFaultCenters(faultCenters, func(fc models.FaultCenter) (string, bool, error) {
+			// 尝试从缓存中获取事件，缓存中不存在视为未命中
 			event, err := pts.ctx.Redis.Alert().GetEventFromCache(tenantId, fc.ID, fingerprint)
 			if err == nil && event.EventId != "" && event.EventId != fingerprint {
-				return event.EventId, nil
+				return event.EventId, true, nil
 			}
+			return "", false, nil
+		})
+		if found {
+			return eventId, nil
 		}
 	}
 
 	// 方法2: 数据库查找作为兜底
-	var alertEvent models.AlertCurEvent
-	err = pts.db.Table("alert_cur_events").Where("tenant_id = ? AND fingerprint = ?", tenantId, fingerprint).First(&alertEvent).Error
+	alertEvent, err := pts.repo.GetCurEventByFingerprint(tenantId, fingerprint)
 	if err == nil && alertEvent.EventId != fingerprint {
 		return alertEvent.EventId, nil
 	}
@@ -103,77 +191,77 @@ func (pts *processTraceService) searchEventInRedisCache(tenantId, searchValue st
 		return "", "", "", false
 	}
 
-	for _, fc := range faultCenters {
+	match, found, err := lookupFaultCenters(faultCenters, func(fc models.FaultCenter) (cachedEventMatch, bool, error) {
 		events, err := pts.ctx.Redis.Alert().GetAllEvents(models.BuildAlertEventCacheKey(tenantId, fc.ID))
 		if err != nil {
-			continue
+			return cachedEventMatch{}, false, fmt.Errorf("故障中心 %s: %w", fc.ID, err)
 		}
 
 		for fingerprint, event := range events {
 			if searchByEventId {
 				// 按eventId搜索，返回规则信息
 				if event.EventId == searchValue && event.RuleName != "" {
-					return event.EventId, event.RuleId, event.RuleName, true
+					return cachedEventMatch{event.EventId, event.RuleId, event.RuleName}, true, nil
 				}
 			} else {
 				// 按指纹搜索，检查eventId和指纹是否匹配
 				if event.EventId == searchValue && fingerprint == searchValue {
-					return event.EventId, event.RuleId, event.RuleName, true
+					return cachedEventMatch{event.EventId, event.RuleId, event.RuleName}, true, nil
 				}
 			}
 		}
+		return cachedEventMatch{}, false, nil
+	})
+	if err != nil {
+		logc.Errorf(pts.ctx.Ctx, "查询故障中心事件缓存失败, tenantId: %s, err: %s", tenantId, err.Error())
 	}
 
-	return "", "", "", false
+	return match.eventId, match.ruleId, match.ruleName, found
 }
 
 // isEventMatchFingerprint 检查事件ID是否匹配给定指纹
 func (pts *processTraceService) isEventMatchFingerprint(tenantId, eventId, targetFingerprint string) bool {
+	// 优先使用指纹索引，未命中或不匹配时再遍历故障中心
+	indexes, _ := pts.ctx.Redis.Alert().GetFingerprintIndex(tenantId, targetFingerprint)
+	for _, index := range indexes {
+		if index.EventId == eventId {
+			return true
+		}
+	}
+
 	faultCenters, err := pts.getFaultCenters(tenantId)
 	if err != nil {
 		return false
 	}
 
-	for _, fc := range faultCenters {
+	_, found, err := lookupFaultCenters(faultCenters, func(fc models.FaultCenter) (struct{}, bool, error) {
 		events, err := pts.ctx.Redis.Alert().GetAllEvents(models.BuildAlertEventCacheKey(tenantId, fc.ID))
 		if err != nil {
-			continue
+			return struct{}{}, false, fmt.Errorf("故障中心 %s: %w", fc.ID, err)
 		}
 
-		for fingerprint, event := range events {
-			if event.EventId == eventId && fingerprint == targetFingerprint {
-				return true
-			}
-		}
+		event, ok := events[targetFingerprint]
+		return struct{}{}, ok && event.EventId == eventId, nil
+	})
+	if err != nil {
+		logc.Errorf(pts.ctx.Ctx, "查询故障中心事件缓存失败, tenantId: %s, err: %s", tenantId, err.Error())
 	}
 
-	return false
+	return found
 }
 
 // getRuleInfoFromEvent 从事件获取规则信息
 func (pts *processTraceService) getRuleInfoFromEvent(tenantId, eventId string) (ruleId string, ruleName string) {
-	// 方法1: 首先尝试通过eventId直接从历史事件表查询
-	var historyEvent models.AlertHisEvent
-	err := pts.db.Table("alert_his_events").Where("tenant_id = ? AND event_id = ?", tenantId, eventId).
-		Select("rule_id, rule_name").First(&historyEvent).Error
-
-	if err == nil && historyEvent.RuleName != "" {
-		return historyEvent.RuleId, historyEvent.RuleName
-	}
-
-	// 方法2: 尝试从当前事件表查询
-	var currentEvent models.AlertCurEvent
-	err = pts.db.Table("alert_cur_events").Where("tenant_id = ? AND event_id = ?", tenantId, eventId).
-		Select("rule_id, rule_name").First(&currentEvent).Error
-
-	if err == nil && currentEvent.RuleName != "" {
-		return currentEvent.RuleId, currentEvent.RuleName
+	// 方法1: 先从历史事件表、再从当前事件表查询
+	ruleId, ruleName, err := pts.repo.GetRuleInfoByEventId(tenantId, eventId)
+	if err == nil && ruleName != "" {
+		return ruleId, ruleName
 	}
 
-	// 方法3: 从Redis缓存中查找（主要数据源）
-	_, ruleId, ruleName, found := pts.searchEventInRedisCache(tenantId, eventId, true)
+	// 方法2: 从Redis缓存中查找（主要数据源）
+	_, cachedRuleId, cachedRuleName, found := pts.searchEventInRedisCache(tenantId, eventId, true)
 	if found {
-		return ruleId, ruleName
+		return cachedRuleId, cachedRuleName
 	}
 
 	// 如果都找不到，返回空值
@@ -183,10 +271,9 @@ func (pts *processTraceService) getRuleInfoFromEvent(tenantId, eventId string) (
 // CreateProcessTrace 创建处理流程追踪记录
 func (pts *processTraceService) CreateProcessTrace(tenantId, eventId, faultCenterId, assignedUser string) (*models.ProcessTrace, error) {
 	// 检查是否已存在处理流程记录
-	var existing models.ProcessTrace
-	err := pts.db.Where("tenant_id = ? AND event_id = ?", tenantId, eventId).First(&existing).Error
+	existing, err := pts.repo.GetByEventId(tenantId, eventId)
 	if err == nil {
-		return &existing, nil // 已存在，直接返回
+		return existing, nil // 已存在，直接返回
 	}
 
 	// 获取规则信息
@@ -224,7 +311,7 @@ func (pts *processTraceService) CreateProcessTrace(tenantId, eventId, faultCente
 		},
 	}
 
-	err = pts.db.Create(processTrace).Error
+	err = pts.repo.Create(processTrace)
 	if err != nil {
 		return nil, fmt.Errorf("创建处理流程追踪记录失败: %v", err)
 	}
@@ -268,8 +355,7 @@ func (pts *processTraceService) GetProcessTraceByFingerprint(tenantId, fingerpri
 	}
 
 	// 方法3: 遍历ProcessTrace表，寻找可能的匹配（兜底方法）
-	var processTraces []models.ProcessTrace
-	err = pts.db.Where("tenant_id = ?", tenantId).Find(&processTraces).Error
+	processTraces, err := pts.repo.ListByTenant(tenantId)
 	if err == nil {
 		for _, pt := range processTraces {
 			// 如果eventId就是fingerprint，直接返回
@@ -335,8 +421,7 @@ func (pts *processTraceService) getStatusChineseName(status models.ProcessTraceS
 
 // UpdateProcessStatus 更新处理状态
 func (pts *processTraceService) UpdateProcessStatus(tenantId, eventId, operator string, status models.ProcessTraceStatus, assignedUser, description string) error {
-	var processTrace models.ProcessTrace
-	err := pts.db.Where("tenant_id = ? AND event_id = ?", tenantId, eventId).First(&processTrace).Error
+	processTrace, err := pts.repo.GetByEventId(tenantId, eventId)
 	if err != nil {
 		return fmt.Errorf("未找到处理流程追踪记录: %v", err)
 	}
@@ -364,7 +449,7 @@ func (pts *processTraceService) UpdateProcessStatus(tenantId, eventId, operator
 		processTrace.EndTime = time.Now().Unix()
 	}
 
-	err = pts.db.Save(&processTrace).Error
+	err = pts.repo.Update(processTrace)
 	if err != nil {
 		return fmt.Errorf("更新处理状态失败: %v", err)
 	}
@@ -396,8 +481,7 @@ func (pts *processTraceService) UpdateProcessStatus(tenantId, eventId, operator
 
 // UpdateAIAnalysis 更新AI分析结果
 func (pts *processTraceService) UpdateAIAnalysis(tenantId, eventId, stepName string, analysisData *models.AIAnalysisData) error {
-	var processTrace models.ProcessTrace
-	err := pts.db.Where("tenant_id = ? AND event_id = ?", tenantId, eventId).First(&processTrace).Error
+	processTrace, err := pts.repo.GetByEventId(tenantId, eventId)
 	if err != nil {
 		return fmt.Errorf("未找到处理流程追踪记录: %v", err)
 	}
@@ -407,7 +491,7 @@ func (pts *processTraceService) UpdateAIAnalysis(tenantId, eventId, stepName str
 		return err
 	}
 
-	err = pts.db.Save(&processTrace).Error
+	err = pts.repo.Update(processTrace)
 	if err != nil {
 		return fmt.Errorf("更新AI分析结果失败: %v", err)
 	}
@@ -437,7 +521,7 @@ func (pts *processTraceService) LogOperation(tenantId, eventId, processId, opera
 		UserAgent:     userAgent,
 	}
 
-	err := pts.db.Create(log).Error
+	err := pts.logRepo.Create(log)
 	if err != nil {
 		return fmt.Errorf("记录操作日志失败: %v", err)
 	}
@@ -476,36 +560,164 @@ func (pts *processTraceService) GetProcessStatistics(tenantId string, startTime,
 	var statistics map[string]interface{} = make(map[string]interface{})
 
 	// 总处理流程数
-	var totalCount int64
-	err := pts.db.Model(&models.ProcessTrace{}).Where("tenant_id = ? AND created_at BETWEEN ? AND ?", tenantId, startTime, endTime).Count(&totalCount).Error
+	totalCount, err := pts.repo.CountInRange(tenantId, "", startTime, endTime)
 	if err != nil {
 		return nil, fmt.Errorf("获取总处理流程数失败: %v", err)
 	}
 	statistics["totalCount"] = totalCount
 
 	// 已完成流程数
-	var completedCount int64
-	err = pts.db.Model(&models.ProcessTrace{}).Where("tenant_id = ? AND current_status = ? AND created_at BETWEEN ? AND ?", tenantId, models.ProcessStatusCompleted, startTime, endTime).Count(&completedCount).Error
+	completedCount, err := pts.repo.CountInRange(tenantId, models.ProcessStatusCompleted, startTime, endTime)
 	if err != nil {
 		return nil, fmt.Errorf("获取已完成流程数失败: %v", err)
 	}
 	statistics["completedCount"] = completedCount
 
 	// 平均处理时长
-	var avgDuration float64
-	err = pts.db.Model(&models.ProcessTrace{}).Select("COALESCE(AVG(end_time - start_time), 0) as avg_duration").Where("tenant_id = ? AND current_status = ? AND end_time > 0 AND created_at BETWEEN ? AND ?", tenantId, models.ProcessStatusCompleted, startTime, endTime).Scan(&avgDuration).Error
+	avgDuration, err := pts.repo.AvgCompletedDuration(tenantId, startTime, endTime)
 	if err != nil {
 		return nil, fmt.Errorf("获取平均处理时长失败: %v", err)
 	}
 	statistics["avgDuration"] = avgDuration
 
 	// 各状态分布
-	var statusDistribution []map[string]interface{}
-	err = pts.db.Model(&models.ProcessTrace{}).Select("current_status, COUNT(*) as count").Where("tenant_id = ? AND created_at BETWEEN ? AND ?", tenantId, startTime, endTime).Group("current_status").Scan(&statusDistribution).Error
+	statusDistribution, err := pts.repo.StatusDistribution(tenantId, startTime, endTime)
 	if err != nil {
 		return nil, fmt.Errorf("获取状态分布失败: %v", err)
 	}
 	statistics["statusDistribution"] = statusDistribution
 
+	// 各操作类型次数：近期读取明细日志，超过保留期的部分读取按天汇总
+	operationDistribution, approximate, err := pts.getOperationDistribution(tenantId, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("获取操作类型分布失败: %v", err)
+	}
+	statistics["operationDistribution"] = operationDistribution
+	// 首尾不完整的日期中部分汇总记录按时间比例估算时为 true
+	statistics["operationDistributionApproximate"] = approximate
+
 	return statistics, nil
 }
+
+// getOperationDistribution 合并明细日志与汇总日志的操作类型次数
+// 明细日志汇总后即删除，两者不会重复计数。完全落在范围内的日期直接累加汇总次数；
+// 首尾不完整的日期根据汇总记录的首末操作时间判断，整段在范围内或范围外时精确计入或排除，
+// 与范围部分重叠时按重叠时长比例估算，并通过 approximate 标记结果为近似值
+func (pts *processTraceService) getOperationDistribution(tenantId string, startTime, endTime int64) (map[string]int64, bool, error) {
+	distribution, err := pts.logRepo.CountByOperationType(tenantId, startTime, endTime)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if startDate, endDate, ok := fullDayRange(startTime, endTime); ok {
+		rollups, err := pts.logRepo.CountRollupByOperationType(tenantId, startDate, endDate)
+		if err != nil {
+			return nil, false, err
+		}
+		for operationType, count := range rollups {
+			distribution[operationType] += count
+		}
+	}
+
+	partials, err := pts.logRepo.ListRollupsByDates(tenantId, partialDays(startTime, endTime))
+	if err != nil {
+		return nil, false, err
+	}
+
+	approximate := false
+	for _, rollup := range partials {
+		count, exact := prorateRollup(rollup, startTime, endTime)
+		distribution[rollup.OperationType] += count
+		if !exact {
+			approximate = true
+		}
+	}
+
+	return distribution, approximate, nil
+}
+
+// prorateRollup 计算汇总记录落在 [startTime, endTime] 内的操作次数
+// 汇总记录只保留首末操作时间，部分重叠时假设操作在首末时间之间均匀分布，返回的 exact 为 false
+func prorateRollup(rollup models.ProcessOperationLogRollup, startTime, endTime int64) (int64, bool) {
+	if rollup.FirstTime >= startTime && rollup.LastTime <= endTime {
+		return rollup.Count, true
+	}
+	if rollup.LastTime < startTime || rollup.FirstTime > endTime {
+		return 0, true
+	}
+
+	overlap := min(rollup.LastTime, endTime) - max(rollup.FirstTime, startTime)
+	span := rollup.LastTime - rollup.FirstTime
+	return int64(math.Round(float64(rollup.Count) * float64(overlap) / float64(span))), false
+}
+
+// utcDay 返回时间戳所在 UTC 自然日的零点
+func utcDay(ts int64) time.Time {
+	t := time.Unix(ts, 0).UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// dayCovered 判断自然日是否完全落在 [startTime, endTime] 内
+func dayCovered(day time.Time, startTime, endTime int64) bool {
+	return day.Unix() >= startTime && day.AddDate(0, 0, 1).Unix()-1 <= endTime
+}
+
+// fullDayRange 返回完全落在 [startTime, endTime] 内的 UTC 自然日范围，格式 2006-01-02
+func fullDayRange(startTime, endTime int64) (string, string, bool) {
+	first := utcDay(startTime)
+	if !dayCovered(first, startTime, endTime) {
+		first = first.AddDate(0, 0, 1)
+	}
+
+	last := utcDay(endTime)
+	if !dayCovered(last, startTime, endTime) {
+		last = last.AddDate(0, 0, -1)
+	}
+
+	if last.Before(first) {
+		return "", "", false
+	}
+	return first.Format(models.RollupDateLayout), last.Format(models.RollupDateLayout), true
+}
+
+// partialDays 返回 [startTime, endTime] 首尾只覆盖了一部分的 UTC 自然日
+func partialDays(startTime, endTime int64) []string {
+	if endTime < startTime {
+		return nil
+	}
+
+	var dates []string
+	first, last := utcDay(startTime), utcDay(endTime)
+	if !dayCovered(first, startTime, endTime) {
+		dates = append(dates, first.Format(models.RollupDateLayout))
+	}
+	if !last.Equal(first) && !dayCovered(last, startTime, endTime) {
+		dates = append(dates, last.Format(models.RollupDateLayout))
+	}
+	return dates
+}
+
+// RollupOperationLogs 将超过保留期的操作日志按天汇总后删除明细，保留期按 UTC 自然日对齐
+func (pts *processTraceService) RollupOperationLogs(cfg config.ProcessOperationLogRetention) error {
+	tenantIds, err := pts.logRepo.ListTenantIds()
+	if err != nil {
+		return fmt.Errorf("获取操作日志租户列表失败: %v", err)
+	}
+
+	today := utcDay(time.Now().Unix())
+	for _, tenantId := range tenantIds {
+		days := cfg.GetRetentionDays(tenantId)
+		before := today.AddDate(0, 0, -days).Unix()
+
+		count, err := pts.logRepo.RollupBefore(tenantId, before)
+		if err != nil {
+			logc.Errorf(pts.ctx.Ctx, "汇总操作日志失败, tenantId: %s, err: %s", tenantId, err.Error())
+			continue
+		}
+		if count > 0 {
+			logc.Infof(pts.ctx.Ctx, "操作日志汇总完成, tenantId: %s, 保留天数: %d, 汇总明细: %d", tenantId, days, count)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,313 @@
+package services
+
+import (
+	"alertHub/internal/models"
+	"alertHub/internal/repo"
+	"slices"
+	"sort"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// memProcessTraceRepo 基于内存的 ProcessTraceRepo 实现，用于脱离数据库测试服务逻辑
+type memProcessTraceRepo struct {
+	mu        sync.Mutex
+	traces    map[string]models.ProcessTrace // key: 流程ID
+	curEvents []models.AlertCurEvent
+	rules     map[string][2]string // key: 事件ID，value: 规则ID、规则名称
+}
+
+func newMemProcessTraceRepo() *memProcessTraceRepo {
+	return &memProcessTraceRepo{
+		traces: make(map[string]models.ProcessTrace),
+		rules:  make(map[string][2]string),
+	}
+}
+
+func (m *memProcessTraceRepo) Create(processTrace *models.ProcessTrace) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.traces[processTrace.ID] = *processTrace
+	return nil
+}
+
+func (m *memProcessTraceRepo) GetByEventId(tenantId, eventId string) (*models.ProcessTrace, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, pt := range m.traces {
+		if pt.TenantId == tenantId && pt.EventId == eventId {
+			return &pt, nil
+		}
+	}
+	return &models.ProcessTrace{}, gorm.ErrRecordNotFound
+}
+
+func (m *memProcessTraceRepo) Update(processTrace *models.ProcessTrace) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.traces[processTrace.ID] = *processTrace
+	return nil
+}
+
+func (m *memProcessTraceRepo) GetList(tenantId string, page, pageSize int, status string) ([]models.ProcessTrace, int64, error) {
+	return m.filter(func(pt models.ProcessTrace) bool {
+		return pt.TenantId == tenantId && (status == "" || string(pt.CurrentStatus) == status)
+	}, page, pageSize)
+}
+
+func (m *memProcessTraceRepo) GetListWithFilters(tenantId, eventId, faultCenterId string, page, pageSize int) ([]models.ProcessTrace, int64, error) {
+	return m.filter(func(pt models.ProcessTrace) bool {
+		return pt.TenantId == tenantId &&
+			(eventId == "" || pt.EventId == eventId) &&
+			(faultCenterId == "" || pt.FaultCenterId == faultCenterId)
+	}, page, pageSize)
+}
+
+func (m *memProcessTraceRepo) GetListWithRuleNames(tenantId, eventId, faultCenterId string, page, pageSize int) ([]models.ProcessTrace, int64, error) {
+	return m.GetListWithFilters(tenantId, eventId, faultCenterId, page, pageSize)
+}
+
+// filter 按条件过滤并按创建时间倒序分页
+func (m *memProcessTraceRepo) filter(match func(pt models.ProcessTrace) bool, page, pageSize int) ([]models.ProcessTrace, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var list []models.ProcessTrace
+	for _, pt := range m.traces {
+		if match(pt) {
+			list = append(list, pt)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt > list[j].CreatedAt })
+
+	total := int64(len(list))
+	offset := (page - 1) * pageSize
+	if offset >= len(list) {
+		return nil, total, nil
+	}
+	return list[offset:min(offset+pageSize, len(list))], total, nil
+}
+
+func (m *memProcessTraceRepo) Delete(tenantId, processId string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if pt, ok := m.traces[processId]; ok && pt.TenantId == tenantId {
+		delete(m.traces, processId)
+	}
+	return nil
+}
+
+func (m *memProcessTraceRepo) ListByTenant(tenantId string) ([]models.ProcessTrace, error) {
+	list, _, err := m.filter(func(pt models.ProcessTrace) bool { return pt.TenantId == tenantId }, 1, len(m.traces)+1)
+	return list, err
+}
+
+func (m *memProcessTraceRepo) inRange(tenantId string, startTime, endTime int64) []models.ProcessTrace {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var list []models.ProcessTrace
+	for _, pt := range m.traces {
+		if pt.TenantId == tenantId && pt.CreatedAt >= startTime && pt.CreatedAt <= endTime {
+			list = append(list, pt)
+		}
+	}
+	return list
+}
+
+func (m *memProcessTraceRepo) CountInRange(tenantId string, status models.ProcessTraceStatus, startTime, endTime int64) (int64, error) {
+	var count int64
+	for _, pt := range m.inRange(tenantId, startTime, endTime) {
+		if status == "" || pt.CurrentStatus == status {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (m *memProcessTraceRepo) AvgCompletedDuration(tenantId string, startTime, endTime int64) (float64, error) {
+	var total, count int64
+	for _, pt := range m.inRange(tenantId, startTime, endTime) {
+		if pt.CurrentStatus == models.ProcessStatusCompleted && pt.EndTime > 0 {
+			total += pt.EndTime - pt.StartTime
+			count++
+		}
+	}
+	if count == 0 {
+		return 0, nil
+	}
+	return float64(total) / float64(count), nil
+}
+
+func (m *memProcessTraceRepo) StatusDistribution(tenantId string, startTime, endTime int64) ([]map[string]interface{}, error) {
+	counts := make(map[models.ProcessTraceStatus]int64)
+	for _, pt := range m.inRange(tenantId, startTime, endTime) {
+		counts[pt.CurrentStatus]++
+	}
+
+	var distribution []map[string]interface{}
+	for status, count := range counts {
+		distribution = append(distribution, map[string]interface{}{"current_status": string(status), "count": count})
+	}
+	return distribution, nil
+}
+
+func (m *memProcessTraceRepo) GetCurEventByFingerprint(tenantId, fingerprint string) (models.AlertCurEvent, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, event := range m.curEvents {
+		if event.TenantId == tenantId && event.Fingerprint == fingerprint {
+			return event, nil
+		}
+	}
+	return models.AlertCurEvent{}, gorm.ErrRecordNotFound
+}
+
+func (m *memProcessTraceRepo) GetRuleInfoByEventId(tenantId, eventId string) (string, string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if rule, ok := m.rules[eventId]; ok {
+		return rule[0], rule[1], nil
+	}
+	return "", "", gorm.ErrRecordNotFound
+}
+
+// memProcessOperationLogRepo 基于内存的 ProcessOperationLogRepo 实现
+type memProcessOperationLogRepo struct {
+	mu      sync.Mutex
+	logs    []models.ProcessOperationLog
+	rollups []models.ProcessOperationLogRollup
+}
+
+func newMemProcessOperationLogRepo() *memProcessOperationLogRepo {
+	return &memProcessOperationLogRepo{}
+}
+
+func (m *memProcessOperationLogRepo) Create(log *models.ProcessOperationLog) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logs = append(m.logs, *log)
+	return nil
+}
+
+func (m *memProcessOperationLogRepo) paginate(match func(log models.ProcessOperationLog) bool, page, pageSize int) ([]models.ProcessOperationLog, int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var list []models.ProcessOperationLog
+	for _, log := range m.logs {
+		if match(log) {
+			list = append(list, log)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].OperationTime > list[j].OperationTime })
+
+	total := int64(len(list))
+	offset := (page - 1) * pageSize
+	if offset >= len(list) {
+		return nil, total, nil
+	}
+	return list[offset:min(offset+pageSize, len(list))], total, nil
+}
+
+func (m *memProcessOperationLogRepo) GetList(tenantId, eventId string, page, pageSize int) ([]models.ProcessOperationLog, int64, error) {
+	return m.paginate(func(log models.ProcessOperationLog) bool {
+		return log.TenantId == tenantId && log.EventId == eventId
+	}, page, pageSize)
+}
+
+func (m *memProcessOperationLogRepo) GetByProcessId(tenantId, processId string, page, pageSize int) ([]models.ProcessOperationLog, int64, error) {
+	return m.paginate(func(log models.ProcessOperationLog) bool {
+		return log.TenantId == tenantId && log.ProcessId == processId
+	}, page, pageSize)
+}
+
+func (m *memProcessOperationLogRepo) ListTenantIds() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	seen := make(map[string]bool)
+	var tenantIds []string
+	for _, log := range m.logs {
+		if !seen[log.TenantId] {
+			seen[log.TenantId] = true
+			tenantIds = append(tenantIds, log.TenantId)
+		}
+	}
+	return tenantIds, nil
+}
+
+// RollupBefore 与 gorm 实现一致：按 流程/日期/操作类型 汇总后删除明细
+func (m *memProcessOperationLogRepo) RollupBefore(tenantId string, before int64) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var expired, kept []models.ProcessOperationLog
+	for _, log := range m.logs {
+		if log.TenantId == tenantId && log.OperationTime < before {
+			expired = append(expired, log)
+		} else {
+			kept = append(kept, log)
+		}
+	}
+
+	for _, rollup := range repo.BuildOperationLogRollups(expired) {
+		merged := false
+		for i, existing := range m.rollups {
+			if existing.TenantId == rollup.TenantId && existing.ProcessId == rollup.ProcessId &&
+				existing.Date == rollup.Date && existing.OperationType == rollup.OperationType {
+				m.rollups[i].Count += rollup.Count
+				m.rollups[i].FirstTime = min(existing.FirstTime, rollup.FirstTime)
+				m.rollups[i].LastTime = max(existing.LastTime, rollup.LastTime)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			m.rollups = append(m.rollups, rollup)
+		}
+	}
+	m.logs = kept
+
+	return int64(len(expired)), nil
+}
+
+func (m *memProcessOperationLogRepo) CountByOperationType(tenantId string, startTime, endTime int64) (map[string]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counts := make(map[string]int64)
+	for _, log := range m.logs {
+		if log.TenantId == tenantId && log.OperationTime >= startTime && log.OperationTime <= endTime {
+			counts[log.OperationType]++
+		}
+	}
+	return counts, nil
+}
+
+func (m *memProcessOperationLogRepo) CountRollupByOperationType(tenantId, startDate, endDate string) (map[string]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counts := make(map[string]int64)
+	for _, rollup := range m.rollups {
+		if rollup.TenantId == tenantId && rollup.Date >= startDate && rollup.Date <= endDate {
+			counts[rollup.OperationType] += rollup.Count
+		}
+	}
+	return counts, nil
+}
+
+func (m *memProcessOperationLogRepo) ListRollupsByDates(tenantId string, dates []string) ([]models.ProcessOperationLogRollup, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var rollups []models.ProcessOperationLogRollup
+	for _, rollup := range m.rollups {
+		if rollup.TenantId == tenantId && slices.Contains(dates, rollup.Date) {
+			rollups = append(rollups, rollup)
+		}
+	}
+	return rollups, nil
+}
@@ -0,0 +1,512 @@
+package services
+
+import (
+	"alertHub/internal/ctx"
+	"alertHub/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestProcessTraceService() (*processTraceService, *memProcessTraceRepo, *memProcessOperationLogRepo) {
+	traceRepo := newMemProcessTraceRepo()
+	logRepo := newMemProcessOperationLogRepo()
+	c := &ctx.Context{Ctx: context.Background()}
+	return NewInterProcessTraceServiceWithRepo(c, traceRepo, logRepo).(*processTraceService), traceRepo, logRepo
+}
+
+func TestCreateProcessTrace(t *testing.T) {
+	pts, traceRepo, logRepo := newTestProcessTraceService()
+	traceRepo.rules["e-1"] = [2]string{"r-1", "CPU 使用率过高"}
+
+	created, err := pts.CreateProcessTrace("t-1", "e-1", "fc-1", "alice")
+	if err != nil {
+		t.Fatalf("CreateProcessTrace err: %v", err)
+	}
+	if created.RuleId != "r-1" || created.RuleName != "CPU 使用率过高" {
+		t.Fatalf("规则信息 = %s/%s, want r-1/CPU 使用率过高", created.RuleId, created.RuleName)
+	}
+	if created.CurrentStatus != models.ProcessStatusDetected || len(created.ProcessSteps) != 1 {
+		t.Fatalf("初始状态 = %s, 步骤数 = %d", created.CurrentStatus, len(created.ProcessSteps))
+	}
+
+	// 重复创建返回已有记录
+	again, err := pts.CreateProcessTrace("t-1", "e-1", "fc-1", "bob")
+	if err != nil {
+		t.Fatalf("CreateProcessTrace err: %v", err)
+	}
+	if again.ID != created.ID || len(traceRepo.traces) != 1 {
+		t.Fatalf("重复创建不应生成新记录, got %d 条", len(traceRepo.traces))
+	}
+
+	logs, total, err := pts.GetOperationLogs("t-1", "e-1", 1, 10)
+	if err != nil {
+		t.Fatalf("GetOperationLogs err: %v", err)
+	}
+	if total != 1 || logs[0].OperationType != "create_process" || logs[0].Operator != "alice" {
+		t.Fatalf("操作日志 = %+v", logRepo.logs)
+	}
+}
+
+func TestCreateProcessTraceFallsBackToEventIdAsRuleName(t *testing.T) {
+	pts, _, _ := newTestProcessTraceService()
+	pts.ctx.DB = &fakeEntryRepo{faultCenter: &fakeFaultCenterRepo{}}
+
+	created, err := pts.CreateProcessTrace("t-1", "e-unknown", "fc-1", "alice")
+	if err != nil {
+		t.Fatalf("CreateProcessTrace err: %v", err)
+	}
+	if created.RuleName != "e-unknown" {
+		t.Fatalf("未找到规则时应使用事件ID作为名称, got %q", created.RuleName)
+	}
+}
+
+func TestUpdateProcessStatus(t *testing.T) {
+	pts, traceRepo, _ := newTestProcessTraceService()
+	traceRepo.rules["e-1"] = [2]string{"r-1", "磁盘空间不足"}
+	if _, err := pts.CreateProcessTrace("t-1", "e-1", "fc-1", "alice"); err != nil {
+		t.Fatalf("CreateProcessTrace err: %v", err)
+	}
+
+	if err := pts.UpdateProcessStatus("t-1", "e-1", "alice", models.ProcessStatusCompleted, "bob", "已扩容"); err != nil {
+		t.Fatalf("UpdateProcessStatus err: %v", err)
+	}
+
+	got, err := pts.GetProcessTrace("t-1", "e-1")
+	if err != nil {
+		t.Fatalf("GetProcessTrace err: %v", err)
+	}
+	if got.CurrentStatus != models.ProcessStatusCompleted || got.AssignedUser != "bob" || got.EndTime == 0 {
+		t.Fatalf("更新后 状态=%s 处理人=%s 结束时间=%d", got.CurrentStatus, got.AssignedUser, got.EndTime)
+	}
+
+	logs, total, _ := pts.GetOperationLogs("t-1", "e-1", 1, 10)
+	if total != 2 {
+		t.Fatalf("操作日志数 = %d, want 2", total)
+	}
+	var found bool
+	for _, log := range logs {
+		if log.OperationType == "update_status" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("缺少 update_status 操作日志")
+	}
+}
+
+func TestUpdateProcessStatusNotFound(t *testing.T) {
+	pts, _, _ := newTestProcessTraceService()
+
+	if err := pts.UpdateProcessStatus("t-1", "missing", "alice", models.ProcessStatusCompleted, "", ""); err == nil {
+		t.Fatal("流程不存在时应返回错误")
+	}
+	if _, err := pts.GetProcessTrace("t-1", "missing"); err == nil {
+		t.Fatal("流程不存在时应返回错误")
+	}
+}
+
+func TestGetProcessStatistics(t *testing.T) {
+	pts, traceRepo, _ := newTestProcessTraceService()
+	seed := []models.ProcessTrace{
+		{ID: "p-1", TenantId: "t-1", CurrentStatus: models.ProcessStatusCompleted, StartTime: 100, EndTime: 160, CreatedAt: 100},
+		{ID: "p-2", TenantId: "t-1", CurrentStatus: models.ProcessStatusCompleted, StartTime: 200, EndTime: 320, CreatedAt: 200},
+		{ID: "p-3", TenantId: "t-1", CurrentStatus: models.ProcessStatusProcessing, StartTime: 300, CreatedAt: 300},
+		{ID: "p-4", TenantId: "t-1", CurrentStatus: models.ProcessStatusCompleted, StartTime: 900, EndTime: 1000, CreatedAt: 900}, // 超出时间范围
+		{ID: "p-5", TenantId: "t-2", CurrentStatus: models.ProcessStatusCompleted, StartTime: 100, EndTime: 200, CreatedAt: 100},  // 其他租户
+	}
+	for i := range seed {
+		_ = traceRepo.Create(&seed[i])
+	}
+
+	stats, err := pts.GetProcessStatistics("t-1", 0, 500)
+	if err != nil {
+		t.Fatalf("GetProcessStatistics err: %v", err)
+	}
+	if stats["totalCount"].(int64) != 3 {
+		t.Fatalf("totalCount = %v, want 3", stats["totalCount"])
+	}
+	if stats["completedCount"].(int64) != 2 {
+		t.Fatalf("completedCount = %v, want 2", stats["completedCount"])
+	}
+	if stats["avgDuration"].(float64) != 90 {
+		t.Fatalf("avgDuration = %v, want 90", stats["avgDuration"])
+	}
+}
+
+// seedOperationLogs 写入指定时间点的操作日志
+func seedOperationLogs(logRepo *memProcessOperationLogRepo, tenantId, operationType string, times ...time.Time) {
+	for i, at := range times {
+		_ = logRepo.Create(&models.ProcessOperationLog{
+			ID:            fmt.Sprintf("%s-%s-%d", tenantId, operationType, i),
+			TenantId:      tenantId,
+			ProcessId:     "p-1",
+			EventId:       "e-1",
+			OperationType: operationType,
+			OperationTime: at.Unix(),
+		})
+	}
+}
+
+func TestOperationDistributionAcrossRollupBoundary(t *testing.T) {
+	pts, _, logRepo := newTestProcessTraceService()
+	day := func(d, h int) time.Time { return time.Date(2025, 3, d, h, 0, 0, 0, time.UTC) }
+
+	seedOperationLogs(logRepo, "t-1", "update_status", day(1, 9), day(1, 18), day(2, 10), day(2, 20), day(3, 8))
+	seedOperationLogs(logRepo, "t-1", "create_process", day(2, 9), day(3, 9))
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       map[string]int64
+	}{
+		{"整天范围", day(2, 0), day(3, 0).Add(-time.Second), map[string]int64{"update_status": 2, "create_process": 1}},
+		{"跨越汇总边界", day(2, 0), day(3, 12), map[string]int64{"update_status": 3, "create_process": 2}},
+		{"全部范围", day(1, 0), day(4, 0).Add(-time.Second), map[string]int64{"update_status": 5, "create_process": 2}},
+	}
+
+	before := make(map[string]map[string]int64)
+	for _, tt := range tests {
+		got, _, err := pts.getOperationDistribution("t-1", tt.start.Unix(), tt.end.Unix())
+		if err != nil {
+			t.Fatalf("%s: err: %v", tt.name, err)
+		}
+		before[tt.name] = got
+	}
+
+	// 汇总第 3 天之前的明细，汇总前后统计结果应一致
+	if _, err := logRepo.RollupBefore("t-1", day(3, 0).Unix()); err != nil {
+		t.Fatalf("RollupBefore err: %v", err)
+	}
+
+	for _, tt := range tests {
+		got, approximate, err := pts.getOperationDistribution("t-1", tt.start.Unix(), tt.end.Unix())
+		if err != nil {
+			t.Fatalf("%s: err: %v", tt.name, err)
+		}
+		if approximate {
+			t.Fatalf("%s: 汇总日期整天落在范围内，结果不应为近似值", tt.name)
+		}
+		if !reflect.DeepEqual(got, tt.want) || !reflect.DeepEqual(before[tt.name], tt.want) {
+			t.Fatalf("%s: 汇总前 %v, 汇总后 %v, want %v", tt.name, before[tt.name], got, tt.want)
+		}
+	}
+}
+
+func TestOperationDistributionPartialRollupDays(t *testing.T) {
+	pts, _, logRepo := newTestProcessTraceService()
+	day := func(d, h int) time.Time { return time.Date(2025, 3, d, h, 0, 0, 0, time.UTC) }
+
+	seedOperationLogs(logRepo, "t-1", "update_status", day(1, 9), day(1, 18), day(2, 10))
+	if _, err := logRepo.RollupBefore("t-1", day(3, 0).Unix()); err != nil {
+		t.Fatalf("RollupBefore err: %v", err)
+	}
+
+	end := day(3, 0).Add(-time.Second)
+	tests := []struct {
+		name            string
+		start           time.Time
+		want            int64
+		wantApproximate bool
+	}{
+		// 第 1 天汇总记录首末时间为 09:00~18:00，整段在范围内，精确计入
+		{"汇总记录整段在范围内", day(1, 8), 3, false},
+		// 整段在范围外，精确排除
+		{"汇总记录整段在范围外", day(1, 19), 1, false},
+		// 12:00 起覆盖 9 小时中的 6 小时，2 次按比例估算为 1 次，不会整天丢弃
+		{"汇总记录部分重叠", day(1, 12), 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, approximate, err := pts.getOperationDistribution("t-1", tt.start.Unix(), end.Unix())
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if got["update_status"] != tt.want || approximate != tt.wantApproximate {
+				t.Fatalf("got (%d, %v), want (%d, %v)", got["update_status"], approximate, tt.want, tt.wantApproximate)
+			}
+		})
+	}
+}
+
+func TestFullDayRange(t *testing.T) {
+	day := func(d, h int) int64 { return time.Date(2025, 3, d, h, 0, 0, 0, time.UTC).Unix() }
+
+	tests := []struct {
+		name        string
+		start, end  int64
+		wantStart   string
+		wantEnd     string
+		wantOK      bool
+		wantPartial []string
+	}{
+		{"整天对齐", day(1, 0), day(3, 0) - 1, "2025-03-01", "2025-03-02", true, nil},
+		{"首尾不完整", day(1, 12), day(3, 12), "2025-03-02", "2025-03-02", true, []string{"2025-03-01", "2025-03-03"}},
+		{"同一天内", day(1, 1), day(1, 23), "", "", false, []string{"2025-03-01"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := fullDayRange(tt.start, tt.end)
+			if start != tt.wantStart || end != tt.wantEnd || ok != tt.wantOK {
+				t.Fatalf("got (%s, %s, %v), want (%s, %s, %v)", start, end, ok, tt.wantStart, tt.wantEnd, tt.wantOK)
+			}
+			if partial := partialDays(tt.start, tt.end); !reflect.DeepEqual(partial, tt.wantPartial) {
+				t.Fatalf("partialDays got %v, want %v", partial, tt.wantPartial)
+			}
+		})
+	}
+}
+
+// newFingerprintTestService 创建带故障中心与告警缓存的测试服务
+func newFingerprintTestService(faultCenterIds ...string) (*processTraceService, *fakeAlertCache) {
+	pts, _, _ := newTestProcessTraceService()
+	faultCenters := &fakeFaultCenterRepo{}
+	for _, id := range faultCenterIds {
+		faultCenters.data = append(faultCenters.data, models.FaultCenter{TenantId: "t-1", ID: id})
+	}
+	alertCache := newFakeAlertCache()
+	pts.ctx.DB = &fakeEntryRepo{faultCenter: faultCenters}
+	pts.ctx.Redis = &fakeEntryCache{alert: alertCache}
+	return pts, alertCache
+}
+
+func TestFingerprintIndexResolvesWithoutScan(t *testing.T) {
+	pts, alertCache := newFingerprintTestService("fc-1", "fc-2", "fc-3")
+	alertCache.PushAlertEvent(&models.AlertCurEvent{TenantId: "t-1", FaultCenterId: "fc-2", Fingerprint: "fp-1", EventId: "e-1"})
+
+	eventId, err := pts.resolveEventIdFromFingerprint("t-1", "fp-1")
+	if err != nil || eventId != "e-1" {
+		t.Fatalf("resolveEventIdFromFingerprint = %q, %v, want e-1", eventId, err)
+	}
+	if !pts.isEventMatchFingerprint("t-1", "e-1", "fp-1") {
+		t.Fatal("isEventMatchFingerprint 应命中索引")
+	}
+	if alertCache.getAllEventsCalls != 0 {
+		t.Fatalf("索引命中时不应遍历故障中心事件, GetAllEvents 调用 %d 次", alertCache.getAllEventsCalls)
+	}
+}
+
+func TestFingerprintIndexKeepsFaultCentersSeparate(t *testing.T) {
+	pts, alertCache := newFingerprintTestService("fc-1", "fc-2")
+	alertCache.PushAlertEvent(&models.AlertCurEvent{TenantId: "t-1", FaultCenterId: "fc-1", Fingerprint: "fp-1", EventId: "e-1"})
+	alertCache.PushAlertEvent(&models.AlertCurEvent{TenantId: "t-1", FaultCenterId: "fc-2", Fingerprint: "fp-1", EventId: "e-2"})
+
+	for _, eventId := range []string{"e-1", "e-2"} {
+		if !pts.isEventMatchFingerprint("t-1", eventId, "fp-1") {
+			t.Fatalf("同一指纹在不同故障中心的事件 %s 应均可匹配", eventId)
+		}
+	}
+	if alertCache.getAllEventsCalls != 0 {
+		t.Fatalf("索引命中时不应遍历故障中心事件, GetAllEvents 调用 %d 次", alertCache.getAllEventsCalls)
+	}
+
+	// 移除一个故障中心的事件不影响另一个
+	alertCache.RemoveAlertEvent("t-1", "fc-1", "fp-1")
+	indexes, _ := alertCache.GetFingerprintIndex("t-1", "fp-1")
+	if len(indexes) != 1 || indexes[0].EventId != "e-2" {
+		t.Fatalf("移除后索引 = %+v, want 仅 fc-2/e-2", indexes)
+	}
+}
+
+func TestFingerprintIndexMissFallsBackToScan(t *testing.T) {
+	pts, alertCache := newFingerprintTestService("fc-1", "fc-2")
+	// 绕过 PushAlertEvent 写入事件，索引中不存在
+	alertCache.putEvent(models.AlertCurEvent{TenantId: "t-1", FaultCenterId: "fc-2", Fingerprint: "fp-1", EventId: "e-1"})
+
+	if !pts.isEventMatchFingerprint("t-1", "e-1", "fp-1") {
+		t.Fatal("索引未命中时应回退遍历故障中心")
+	}
+	if alertCache.getAllEventsCalls == 0 {
+		t.Fatal("索引未命中时应遍历故障中心事件")
+	}
+
+	// 索引与事件缓存不一致时视为失效，同样回退遍历
+	alertCache.PushAlertEvent(&models.AlertCurEvent{TenantId: "t-1", FaultCenterId: "fc-1", Fingerprint: "fp-2", EventId: "e-old"})
+	alertCache.putEvent(models.AlertCurEvent{TenantId: "t-1", FaultCenterId: "fc-1", Fingerprint: "fp-2", EventId: "e-new"})
+	if pts.isEventMatchFingerprint("t-1", "e-old", "fp-2") {
+		t.Fatal("失效的索引不应被信任")
+	}
+	if !pts.isEventMatchFingerprint("t-1", "e-new", "fp-2") {
+		t.Fatal("索引失效时应回退遍历找到当前事件")
+	}
+}
+
+func newLookupFaultCenters(n int) []models.FaultCenter {
+	fcs := make([]models.FaultCenter, n)
+	for i := range fcs {
+		fcs[i] = models.FaultCenter{ID: fmt.Sprintf("fc-%02d", i)}
+	}
+	return fcs
+}
+
+func TestLookupFaultCentersRespectsConcurrencyLimit(t *testing.T) {
+	var inFlight, peak, calls atomic.Int64
+
+	_, found, err := lookupFaultCenters(newLookupFaultCenters(40), func(fc models.FaultCenter) (string, bool, error) {
+		calls.Add(1)
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		inFlight.Add(-1)
+		return "", false, nil
+	})
+
+	if found || err != nil {
+		t.Fatalf("got found=%v err=%v, want miss without error", found, err)
+	}
+	if calls.Load() != 40 {
+		t.Fatalf("got %d lookups, want every fault center queried on a miss", calls.Load())
+	}
+	if p := peak.Load(); p > faultCenterLookupConcurrency || p < 2 {
+		t.Fatalf("peak concurrency %d, want between 2 and %d", p, faultCenterLookupConcurrency)
+	}
+}
+
+func TestLookupFaultCentersReturnsFirstHitInOrder(t *testing.T) {
+	fcs := newLookupFaultCenters(12)
+
+	// 靠后的故障中心先返回，结果仍应为顺序上第一个命中的故障中心
+	got, found, err := lookupFaultCenters(fcs, func(fc models.FaultCenter) (string, bool, error) {
+		switch fc.ID {
+		case "fc-03":
+			time.Sleep(30 * time.Millisecond)
+			return fc.ID, true, nil
+		case "fc-05":
+			return fc.ID, true, nil
+		}
+		return "", false, nil
+	})
+
+	if err != nil || !found {
+		t.Fatalf("got found=%v err=%v, want hit", found, err)
+	}
+	if got != "fc-03" {
+		t.Fatalf("got %s, want fc-03", got)
+	}
+}
+
+func TestLookupFaultCentersStopsDispatchAfterHit(t *testing.T) {
+	var calls atomic.Int64
+
+	got, found, _ := lookupFaultCenters(newLookupFaultCenters(100), func(fc models.FaultCenter) (string, bool, error) {
+		calls.Add(1)
+		if fc.ID == "fc-00" {
+			return fc.ID, true, nil
+		}
+		time.Sleep(5 * time.Millisecond)
+		return "", false, nil
+	})
+
+	if !found || got != "fc-00" {
+		t.Fatalf("got %s found=%v, want fc-00", got, found)
+	}
+	if calls.Load() >= 100 {
+		t.Fatalf("got %d lookups, want remaining fault centers skipped after a hit", calls.Load())
+	}
+}
+
+func TestLookupFaultCentersPropagatesErrors(t *testing.T) {
+	fcs := newLookupFaultCenters(6)
+	errA := errors.New("redis timeout")
+	errB := errors.New("connection refused")
+
+	failing := func(fc models.FaultCenter) (string, bool, error) {
+		switch fc.ID {
+		case "fc-01":
+			return "", false, errA
+		case "fc-04":
+			return "", false, errB
+		}
+		return "", false, nil
+	}
+
+	_, found, err := lookupFaultCenters(fcs, failing)
+	if found {
+		t.Fatal("want miss")
+	}
+	if !errors.Is(err, errA) || !errors.Is(err, errB) {
+		t.Fatalf("got err %v, want both lookup errors", err)
+	}
+
+	// 其他故障中心命中时忽略单个故障中心的查询失败
+	got, found, err := lookupFaultCenters(fcs, func(fc models.FaultCenter) (string, bool, error) {
+		if fc.ID == "fc-03" {
+			return fc.ID, true, nil
+		}
+		return failing(fc)
+	})
+	if err != nil || !found || got != "fc-03" {
+		t.Fatalf("got %s found=%v err=%v, want fc-03 without error", got, found, err)
+	}
+}
+
+// lookupFaultCentersSerial 串行遍历故障中心，作为并发查询的基准
+func lookupFaultCentersSerial[T any](faultCenters []models.FaultCenter, lookup func(fc models.FaultCenter) (T, bool, error)) (T, bool, error) {
+	var errs []error
+	for _, fc := range faultCenters {
+		v, ok, err := lookup(fc)
+		if ok {
+			return v, true, nil
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	var zero T
+	return zero, false, errors.Join(errs...)
+}
+
+// BenchmarkLookupFaultCenters 对比串行与并发查询，单次查询模拟 1ms 的 Redis 往返延迟
+func BenchmarkLookupFaultCenters(b *testing.B) {
+	const latency = time.Millisecond
+	fcs := newLookupFaultCenters(32)
+
+	lookupHitAt := func(hit string) func(fc models.FaultCenter) (string, bool, error) {
+		return func(fc models.FaultCenter) (string, bool, error) {
+			time.Sleep(latency)
+			return fc.ID, fc.ID == hit, nil
+		}
+	}
+
+	cases := []struct {
+		name string
+		hit  string
+	}{
+		{"hit-first", "fc-00"},
+		{"hit-middle", "fc-16"},
+		{"miss", ""},
+	}
+
+	impls := []struct {
+		name   string
+		lookup func([]models.FaultCenter, func(models.FaultCenter) (string, bool, error)) (string, bool, error)
+	}{
+		{"serial", lookupFaultCentersSerial[string]},
+		{"concurrent", lookupFaultCenters[string]},
+	}
+
+	for _, c := range cases {
+		for _, impl := range impls {
+			b.Run(c.name+"/"+impl.name, func(b *testing.B) {
+				lookup := lookupHitAt(c.hit)
+				for i := 0; i < b.N; i++ {
+					if _, found, _ := impl.lookup(fcs, lookup); found != (c.hit != "") {
+						b.Fatalf("found=%v, want %v", found, c.hit != "")
+					}
+				}
+			})
+		}
+	}
+}
@@ -397,7 +397,7 @@ func (rs ruleService) Import(req interface{}) (interface{}, interface{}) {
 			Enabled:              &disable,
 		})
 		if err != nil {
-			logc.Errorf(rs.ctx.Ctx, err.Error())
+			logc.Error(rs.ctx.Ctx, err.Error())
 			continue
 		}
 	}
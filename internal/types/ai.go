@@ -7,6 +7,7 @@ import (
 )
 
 type RequestAiChatContent struct {
+	TenantId string `json:"tenantId" form:"tenantId"`
 	// 规则名称，用来分析告警时，更明确当前是一个什么规则（可选，支持通用机器人）
 	RuleName string `json:"ruleName" form:"ruleName"`
 	RuleId   string `json:"ruleId" form:"ruleId"`
@@ -74,3 +75,21 @@ func (a *RequestAiChatContent) ValidateParams() error {
 	}
 	return nil
 }
+
+// RequestAiUsageQuery 查询 AI 调用用量
+type RequestAiUsageQuery struct {
+	TenantId string `json:"tenantId" form:"tenantId"`
+	// 查询最近的天数（含当天），默认 7 天
+	Days int `json:"days" form:"days"`
+}
+
+// ResponseAiUsage 单日 AI 调用用量与估算费用
+type ResponseAiUsage struct {
+	Date     string `json:"date"`
+	Requests int64  `json:"requests"`
+	// 用量由文本估算而非 Provider 返回的调用次数
+	EstimatedRequests int64   `json:"estimatedRequests"`
+	PromptTokens      int64   `json:"promptTokens"`
+	CompletionTokens  int64   `json:"completionTokens"`
+	Cost              float64 `json:"cost"`
+}
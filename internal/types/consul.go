@@ -1,6 +1,9 @@
 package types
 
-import "alertHub/internal/models"
+import (
+	"alertHub/internal/models"
+	"alertHub/pkg/consul"
+)
 
 // RequestConsulTargetsQuery Consul 目标查询请求
 type RequestConsulTargetsQuery struct {
@@ -19,4 +22,7 @@ type RequestRegisterTarget struct {
 	Job         string            `json:"job"`                            // Job 名称（可选）
 	Tags        []string          `json:"tags"`                           // 标签列表（可选）
 	Labels      map[string]string `json:"labels"`                         // 标签键值对（可选，会转换为 Meta）
+
+	Check        *consul.HealthCheckConfig `json:"check"`        // 健康检查配置（可选，http/tcp/ttl）
+	DatasourceId string                    `json:"datasourceId"` // Consul 数据源ID（可选，默认使用第一个 Consul 数据源）
 }
@@ -53,6 +53,20 @@ type RequestDatasourceQuery struct {
 	Query    string `json:"query" form:"query"`
 }
 
+type RequestDatasourceHealth struct {
+	TenantId string `json:"tenantId" form:"tenantId"`
+	Type     string `json:"type" form:"type"`
+	// Refresh 为 true 时忽略缓存，重新检查
+	Refresh bool `json:"refresh" form:"refresh"`
+}
+
+type ResponseDatasourceHealth struct {
+	Total     int                             `json:"total"`
+	Healthy   int                             `json:"healthy"`
+	Unhealthy int                             `json:"unhealthy"`
+	Results   []models.DatasourceHealthResult `json:"results"`
+}
+
 type RequestQueryMetricsValue struct {
 	DatasourceIds string            `form:"datasourceIds"`
 	Query         string            `form:"query"`
@@ -6,13 +6,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"time"
 )
 
 // NewAiClient 创建 AI 客户端工厂方法
-// 根据 config.Provider 返回对应的实现（dify 或 openai）
+// 根据 config.Provider 返回对应的实现（dify、openai、anthropic 或 gemini）
 func NewAiClient(config *AiConfig) (AiClient, error) {
 	// 参数校验
 	if config.Provider == "" {
@@ -35,6 +36,20 @@ func NewAiClient(config *AiConfig) (AiClient, error) {
 		}
 		return client, nil
 
+	case "anthropic":
+		client := &anthropicClient{config: config}
+		if err := client.Check(context.Background()); err != nil {
+			return nil, err
+		}
+		return client, nil
+
+	case "gemini":
+		client := &geminiClient{config: config}
+		if err := client.Check(context.Background()); err != nil {
+			return nil, err
+		}
+		return client, nil
+
 	default:
 		return nil, fmt.Errorf("不支持的 AI Provider: %s", config.Provider)
 	}
@@ -46,47 +61,39 @@ type difyClient struct {
 	config *AiConfig
 }
 
-// ChatCompletion 调用 Dify 底层 API 获取完整分析结果
-func (c *difyClient) ChatCompletion(ctx context.Context, prompt string) (string, error) {
+// newRequest 构建 Dify 请求，Dify 始终使用 streaming 模式返回
+func (c *difyClient) newRequest(ctx context.Context, prompt string) (*http.Request, error) {
 	requestBody := map[string]interface{}{
-		"inputs":           make(map[string]interface{}),
-		"query":            prompt,
-		"response_mode":    "streaming",
-		"conversation_id":  "",
-		"user":             "alertHub-system",
-		"files":            []interface{}{},
+		"inputs":          make(map[string]interface{}),
+		"query":           prompt,
+		"response_mode":   "streaming",
+		"conversation_id": "",
+		"user":            "alertHub-system",
+		"files":           []interface{}{},
 	}
 
-	body, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", fmt.Errorf("序列化请求体失败: %w", err)
-	}
+	return newJSONRequest(ctx, c.config, c.config.Url, requestBody, map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", c.config.ApiKey),
+	})
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", c.config.Url, bytes.NewReader(body))
+// ChatCompletion 调用 Dify 底层 API 获取完整分析结果
+func (c *difyClient) ChatCompletion(ctx context.Context, prompt string) (string, error) {
+	req, err := c.newRequest(ctx, prompt)
 	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
+		return "", err
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.ApiKey))
-	req.Header.Set("Content-Type", "application/json")
-
-	client := &http.Client{}
-	if c.config.Timeout > 0 {
-		client.Timeout = time.Duration(c.config.Timeout*3) * time.Second
-	}
-
-	resp, err := client.Do(req)
+	body, err := doRequest(req, c.config.Timeout, "Dify")
 	if err != nil {
-		return "", fmt.Errorf("Dify API 调用失败: %w", err)
+		return "", err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Dify API 返回状态码 %d", resp.StatusCode)
-	}
-
-	var fullAnswer string
-	scanner := bufio.NewScanner(resp.Body)
+	var (
+		fullAnswer string
+		usage      Usage
+	)
+	scanner := bufio.NewScanner(bytes.NewReader(body))
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -98,9 +105,8 @@ func (c *difyClient) ChatCompletion(ctx context.Context, prompt string) (string,
 		jsonData := strings.TrimPrefix(line, "data: ")
 
 		var streamEvent struct {
-			Event  string `json:"event"`
-			Answer string `json:"answer"`
-			Data   struct {
+			DifyResponse
+			Data struct {
 				Answer string `json:"answer"`
 			} `json:"data"`
 		}
@@ -109,13 +115,20 @@ func (c *difyClient) ChatCompletion(ctx context.Context, prompt string) (string,
 			continue
 		}
 
+		// message_end 事件携带本次调用的用量
+		if streamEvent.Event == "message_end" {
+			usage = streamEvent.usage()
+		}
+
 		// 优先使用 message_end 事件的完整答案
 		if streamEvent.Event == "message_end" && streamEvent.Data.Answer != "" {
+			c.config.reportUsage(prompt, streamEvent.Data.Answer, usage)
 			return streamEvent.Data.Answer, nil
 		}
 
 		// workflow_finished 事件的输出答案
 		if streamEvent.Event == "workflow_finished" && streamEvent.Data.Answer != "" {
+			c.config.reportUsage(prompt, streamEvent.Data.Answer, usage)
 			return streamEvent.Data.Answer, nil
 		}
 
@@ -129,88 +142,55 @@ func (c *difyClient) ChatCompletion(ctx context.Context, prompt string) (string,
 		return "", fmt.Errorf("读取响应错误: %w", err)
 	}
 
+	c.config.reportUsage(prompt, fullAnswer, usage)
 	return fullAnswer, nil
 }
 
 // StreamCompletion 返回 Dify 流式分析结果通道
-func (c *difyClient) StreamCompletion(ctx context.Context, prompt string) (<-chan string, error) {
-	resultChan := make(chan string, 10)
-
-	go func() {
-		defer close(resultChan)
-
-		// 构建请求体
-		// 注意：请求体必须在 goroutine 内部构建，避免 Body 被多次读取
-		requestBody := map[string]interface{}{
-			"inputs":           make(map[string]interface{}),
-			"query":            prompt,
-			"response_mode":    "streaming",
-			"conversation_id":  "",
-			"user":             "alertHub-system",
-			"files":            []interface{}{},
-		}
-
-		body, err := json.Marshal(requestBody)
-		if err != nil {
-			return
-		}
-
-		req, err := http.NewRequestWithContext(ctx, "POST", c.config.Url, bytes.NewReader(body))
-		if err != nil {
-			return
-		}
-
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.ApiKey))
-		req.Header.Set("Content-Type", "application/json")
-
-		client := &http.Client{}
-		if c.config.Timeout > 0 {
-			client.Timeout = time.Duration(c.config.Timeout*3) * time.Second
-		}
-
-		resp, err := client.Do(req)
-		if err != nil {
-			return
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			return
-		}
-
-		scanner := bufio.NewScanner(resp.Body)
-
-		for scanner.Scan() {
-			line := scanner.Text()
+func (c *difyClient) StreamCompletion(ctx context.Context, prompt string) (<-chan StreamEvent, error) {
+	req, err := c.newRequest(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
 
+	// 首个片段前失败时回退为非流式调用，中途出错时发送终止错误事件
+	resultChan := streamWithFallback(ctx, c.config, "Dify", prompt, func(emit func(string) bool, usage *Usage) error {
+		return doStream(req, c.config.Timeout, func(line string) bool {
 			if !strings.HasPrefix(line, "data: ") {
-				continue
+				return true
 			}
 
 			jsonData := strings.TrimPrefix(line, "data: ")
 
-			var streamEvent struct {
-				Event  string `json:"event"`
-				Answer string `json:"answer"`
+			var streamEvent DifyResponse
+			if err := json.Unmarshal([]byte(jsonData), &streamEvent); err != nil {
+				return true
 			}
 
-			if err := json.Unmarshal([]byte(jsonData), &streamEvent); err != nil {
-				continue
+			if streamEvent.Event == "message_end" {
+				*usage = streamEvent.usage()
 			}
 
 			if streamEvent.Event == "message" && streamEvent.Answer != "" {
-				select {
-				case <-ctx.Done():
-					return
-				case resultChan <- streamEvent.Answer:
-				}
+				return emit(streamEvent.Answer)
 			}
-		}
-	}()
+			return true
+		})
+	}, func() (string, error) {
+		return c.ChatCompletion(ctx, prompt)
+	})
 
 	return resultChan, nil
 }
 
+// usage 获取 message_end 事件中的用量
+func (r DifyResponse) usage() Usage {
+	return Usage{
+		PromptTokens:     r.Metadata.Usage.PromptTokens,
+		CompletionTokens: r.Metadata.Usage.CompletionTokens,
+	}
+}
+
 // Check 验证 Dify 配置是否有效
 func (c *difyClient) Check(ctx context.Context) error {
 	if c.config.Url == "" || c.config.ApiKey == "" {
@@ -223,3 +203,213 @@ func (c *difyClient) Check(ctx context.Context) error {
 
 	return nil
 }
+
+// parseErrorMessage 解析错误响应中的 error.message 字段
+// OpenAI、Anthropic、Gemini 的错误响应均为该结构
+func parseErrorMessage(resp *http.Response) string {
+	var errResp struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	_ = json.NewDecoder(resp.Body).Decode(&errResp)
+	return errResp.Error.Message
+}
+
+// httpClient 各 Provider 共用的客户端，复用连接池
+// 不设置 Timeout，超时由 doRequest、doStream 按请求控制
+var httpClient = &http.Client{Transport: http.DefaultTransport.(*http.Transport).Clone()}
+
+const (
+	// requestMaxAttempts 非流式请求最多尝试次数
+	requestMaxAttempts = 3
+)
+
+// retryBackoff 非流式请求首次重试前的等待时间，之后每次翻倍
+var retryBackoff = 500 * time.Millisecond
+
+// requestTimeout 单次请求超时，未配置时默认 30 秒，按 3 倍放宽以容纳模型生成耗时
+func requestTimeout(timeout int) time.Duration {
+	if timeout <= 0 {
+		timeout = 30
+	}
+	return time.Duration(timeout*3) * time.Second
+}
+
+// newJSONRequest 构建 JSON POST 请求
+// headers 为 Provider 的鉴权请求头，config.Headers 中的自定义请求头最后设置，可覆盖默认值
+func newJSONRequest(ctx context.Context, config *AiConfig, url string, payload any, headers map[string]string) (*http.Request, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求体失败: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("创建请求失败: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		httpReq.Header.Set(k, v)
+	}
+	for k, v := range config.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	return httpReq, nil
+}
+
+// doRequest 发送非流式请求并返回响应体
+// 网络错误、429 和 5xx 响应按指数退避重试，每次尝试单独计算超时
+func doRequest(httpReq *http.Request, timeout int, provider string) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; attempt < requestMaxAttempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-httpReq.Context().Done():
+				return nil, fmt.Errorf("%s API 调用失败: %w", provider, httpReq.Context().Err())
+			case <-time.After(retryBackoff << (attempt - 1)):
+			}
+		}
+
+		body, retryable, err := doRequestOnce(httpReq, timeout, provider)
+		if err == nil {
+			return body, nil
+		}
+		lastErr = err
+		if !retryable {
+			break
+		}
+	}
+
+	return nil, lastErr
+}
+
+// doRequestOnce 发送一次请求，返回响应体以及失败时是否可重试
+func doRequestOnce(httpReq *http.Request, timeout int, provider string) ([]byte, bool, error) {
+	c, cancel := context.WithTimeout(httpReq.Context(), requestTimeout(timeout))
+	defer cancel()
+
+	req := httpReq.Clone(c)
+	if httpReq.GetBody != nil {
+		body, err := httpReq.GetBody()
+		if err != nil {
+			return nil, false, fmt.Errorf("创建请求失败: %w", err)
+		}
+		req.Body = body
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// 调用方取消时不再重试
+		return nil, httpReq.Context().Err() == nil, fmt.Errorf("%s API 调用失败: %w", provider, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+		if msg := parseErrorMessage(resp); msg != "" {
+			return nil, retryable, fmt.Errorf("%s API 返回错误 (%d): %s", provider, resp.StatusCode, msg)
+		}
+		return nil, retryable, fmt.Errorf("%s API 返回状态码 %d", provider, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, httpReq.Context().Err() == nil, fmt.Errorf("读取响应错误: %w", err)
+	}
+	return body, false, nil
+}
+
+// doStream 发送流式请求并逐行回调响应内容，onLine 返回 false 时停止读取
+// 流式响应可能持续数分钟，不能用 http.Client.Timeout 限制整体耗时：
+// 这里在等待响应头或超过同样时长未收到新数据时中断连接
+func doStream(httpReq *http.Request, timeout int, onLine func(line string) bool) error {
+	wait := requestTimeout(timeout)
+
+	c, cancel := context.WithCancel(httpReq.Context())
+	defer cancel()
+
+	// 空闲超时：等待响应头和每收到一行数据后都重新计时
+	idle := time.AfterFunc(wait, cancel)
+	defer idle.Stop()
+
+	resp, err := httpClient.Do(httpReq.WithContext(c))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("返回状态码 %d: %s", resp.StatusCode, parseErrorMessage(resp))
+	}
+	idle.Reset(wait)
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		idle.Reset(wait)
+		if !onLine(scanner.Text()) {
+			return nil
+		}
+	}
+
+	return scanner.Err()
+}
+
+// streamWithFallback 在后台执行流式请求，通过 emit 将内容片段写入返回的通道，stream 解析到的用量写入 usage
+// 首个片段到达前出错、超时或流为空时改为调用非流式接口，调用方仍能拿到完整结果；
+// 已输出部分内容后出错则发送终止错误事件，不再静默结束
+// 已输出内容时按实际输出统计用量，回退时由非流式接口统计
+func streamWithFallback(ctx context.Context, config *AiConfig, provider, prompt string, stream func(emit func(string) bool, usage *Usage) error, fallback func() (string, error)) <-chan StreamEvent {
+	resultChan := make(chan StreamEvent, 10)
+
+	send := func(event StreamEvent) bool {
+		select {
+		case <-ctx.Done():
+			return false
+		case resultChan <- event:
+			return true
+		}
+	}
+
+	go func() {
+		defer close(resultChan)
+
+		var (
+			usage      Usage
+			completion strings.Builder
+		)
+		err := stream(func(content string) bool {
+			completion.WriteString(content)
+			return send(StreamEvent{Content: content})
+		}, &usage)
+
+		emitted := completion.Len() > 0
+		if emitted {
+			config.reportUsage(prompt, completion.String(), usage)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		if emitted {
+			if err != nil {
+				send(StreamEvent{Err: fmt.Errorf("%s 流式响应中断: %w", provider, err)})
+			}
+			return
+		}
+
+		content, fallbackErr := fallback()
+		if fallbackErr != nil {
+			if err != nil {
+				fallbackErr = fmt.Errorf("%s 流式请求失败: %w; 非流式请求失败: %w", provider, err, fallbackErr)
+			}
+			send(StreamEvent{Err: fallbackErr})
+			return
+		}
+		send(StreamEvent{Content: content})
+	}()
+
+	return resultChan
+}
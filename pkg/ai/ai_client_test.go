@@ -0,0 +1,140 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	// 缩短重试等待，避免测试耗时
+	retryBackoff = 10 * time.Millisecond
+	os.Exit(m.Run())
+}
+
+// newStallingOpenAIServer 流式请求先输出 chunks 再挂起，非流式请求返回 fallback
+// fallback 为空时非流式请求返回 500
+func newStallingOpenAIServer(t *testing.T, chunks []string, fallback string, nonStreamCalls *atomic.Int64) *httptest.Server {
+	t.Helper()
+	stall := make(chan struct{})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		if !req.Stream {
+			nonStreamCalls.Add(1)
+			if fallback == "" {
+				w.WriteHeader(http.StatusInternalServerError)
+				_, _ = w.Write([]byte(`{"error":{"message":"upstream overloaded"}}`))
+				return
+			}
+			_, _ = fmt.Fprintf(w, `{"choices":[{"message":{"content":%q}}]}`, fallback)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.WriteHeader(http.StatusOK)
+		for _, chunk := range chunks {
+			_, _ = fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", chunk)
+		}
+		w.(http.Flusher).Flush()
+
+		select {
+		case <-r.Context().Done():
+		case <-stall:
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(stall) })
+	return srv
+}
+
+// collectStream 读取通道直至关闭
+func collectStream(t *testing.T, ch <-chan StreamEvent) []StreamEvent {
+	t.Helper()
+	var events []StreamEvent
+	timeout := time.After(15 * time.Second)
+	for {
+		select {
+		case event, ok := <-ch:
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		case <-timeout:
+			t.Fatalf("stream did not finish, got %+v", events)
+		}
+	}
+}
+
+func newTestOpenAIClient(url string) *openaiClient {
+	return &openaiClient{config: &AiConfig{Provider: "openai", Url: url, ApiKey: "k", Model: "m", Timeout: 1}}
+}
+
+func TestStreamCompletionStallMidStreamSendsErrorEvent(t *testing.T) {
+	t.Parallel()
+	var nonStreamCalls atomic.Int64
+	srv := newStallingOpenAIServer(t, []string{"磁盘", "使用率过高"}, "unused", &nonStreamCalls)
+
+	ch, err := newTestOpenAIClient(srv.URL).StreamCompletion(context.Background(), "analyze")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collectStream(t, ch)
+
+	if len(events) != 3 || events[0].Content != "磁盘" || events[1].Content != "使用率过高" {
+		t.Fatalf("got %+v, want two chunks followed by an error event", events)
+	}
+	if events[2].Err == nil {
+		t.Fatalf("stalled stream must end with an error event, got %+v", events[2])
+	}
+	if n := nonStreamCalls.Load(); n != 0 {
+		t.Fatalf("must not fall back after content was streamed, got %d non-stream calls", n)
+	}
+}
+
+func TestStreamCompletionFallsBackWhenFirstChunkNeverArrives(t *testing.T) {
+	t.Parallel()
+	var nonStreamCalls atomic.Int64
+	srv := newStallingOpenAIServer(t, nil, "完整分析结果", &nonStreamCalls)
+
+	ch, err := newTestOpenAIClient(srv.URL).StreamCompletion(context.Background(), "analyze")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collectStream(t, ch)
+
+	if len(events) != 1 || events[0].Err != nil || events[0].Content != "完整分析结果" {
+		t.Fatalf("got %+v, want the non-streaming answer", events)
+	}
+	if n := nonStreamCalls.Load(); n != 1 {
+		t.Fatalf("got %d non-stream calls, want 1", n)
+	}
+}
+
+func TestStreamCompletionReportsFallbackFailure(t *testing.T) {
+	t.Parallel()
+	var nonStreamCalls atomic.Int64
+	srv := newStallingOpenAIServer(t, nil, "", &nonStreamCalls)
+
+	ch, err := newTestOpenAIClient(srv.URL).StreamCompletion(context.Background(), "analyze")
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collectStream(t, ch)
+
+	if len(events) != 1 || events[0].Err == nil {
+		t.Fatalf("got %+v, want a single error event", events)
+	}
+	// 非流式请求返回 500，按重试上限尝试后报错
+	if n := nonStreamCalls.Load(); n != requestMaxAttempts {
+		t.Fatalf("got %d non-stream calls, want %d", n, requestMaxAttempts)
+	}
+}
@@ -0,0 +1,174 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// anthropicVersion Anthropic Messages API 版本
+	anthropicVersion = "2023-06-01"
+	// anthropicDefaultMaxTokens Anthropic 要求必须指定 max_tokens，未配置时使用该默认值
+	anthropicDefaultMaxTokens = 4096
+)
+
+type (
+	anthropicClient struct {
+		config *AiConfig
+	}
+
+	// AnthropicRequest Anthropic Messages API 请求结构
+	AnthropicRequest struct {
+		Model     string     `json:"model"`
+		Messages  []*Message `json:"messages"`
+		MaxTokens int        `json:"max_tokens"`
+		Stream    bool       `json:"stream,omitempty"`
+	}
+
+	// AnthropicResponse Anthropic Messages API 响应结构，文本位于顶层 content 数组
+	AnthropicResponse struct {
+		ID      string `json:"id"`
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Usage AnthropicUsage `json:"usage"`
+	}
+
+	// AnthropicUsage Anthropic 用量结构
+	AnthropicUsage struct {
+		InputTokens  int `json:"input_tokens"`
+		OutputTokens int `json:"output_tokens"`
+	}
+
+	// AnthropicStreamEvent Anthropic 流式事件结构
+	// 输入用量位于 message_start 事件的 message.usage，输出用量位于 message_delta 事件的 usage
+	AnthropicStreamEvent struct {
+		Type  string `json:"type"`
+		Delta struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"delta"`
+		Message struct {
+			Usage AnthropicUsage `json:"usage"`
+		} `json:"message"`
+		Usage AnthropicUsage `json:"usage"`
+	}
+)
+
+// newRequest 构建 Anthropic 请求
+func (c *anthropicClient) newRequest(ctx context.Context, prompt string, stream bool) (*http.Request, error) {
+	maxTokens := c.config.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = anthropicDefaultMaxTokens
+	}
+
+	// 直接使用用户配置的完整 URL，例如：https://api.anthropic.com/v1/messages
+	return newJSONRequest(ctx, c.config, c.config.Url, AnthropicRequest{
+		Model: c.config.Model,
+		Messages: []*Message{
+			{Role: "user", Content: prompt},
+		},
+		MaxTokens: maxTokens,
+		Stream:    stream,
+	}, map[string]string{
+		"x-api-key":         c.config.ApiKey,
+		"anthropic-version": anthropicVersion,
+	})
+}
+
+// ChatCompletion 调用 Anthropic API（非流式）
+func (c *anthropicClient) ChatCompletion(ctx context.Context, prompt string) (string, error) {
+	httpReq, err := c.newRequest(ctx, prompt, false)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := doRequest(httpReq, c.config.Timeout, "Anthropic")
+	if err != nil {
+		return "", err
+	}
+
+	var respData AnthropicResponse
+	if err := json.Unmarshal(body, &respData); err != nil {
+		return "", fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	var content strings.Builder
+	for _, block := range respData.Content {
+		if block.Type == "text" {
+			content.WriteString(block.Text)
+		}
+	}
+	if content.Len() == 0 {
+		return "", fmt.Errorf("Anthropic API 返回空内容")
+	}
+
+	c.config.reportUsage(prompt, content.String(), Usage{
+		PromptTokens:     respData.Usage.InputTokens,
+		CompletionTokens: respData.Usage.OutputTokens,
+	})
+	return content.String(), nil
+}
+
+// StreamCompletion 调用 Anthropic API（流式）
+func (c *anthropicClient) StreamCompletion(ctx context.Context, prompt string) (<-chan StreamEvent, error) {
+	// 使用后台context而不是外部context，避免请求过期
+	httpReq, err := c.newRequest(context.Background(), prompt, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// 处理 SSE 流式响应，文本位于 content_block_delta 事件的 delta.text
+	// 首个片段前失败时回退为非流式调用
+	resultChan := streamWithFallback(ctx, c.config, "Anthropic", prompt, func(emit func(string) bool, usage *Usage) error {
+		return doStream(httpReq, c.config.Timeout, func(line string) bool {
+			if !strings.HasPrefix(line, "data: ") {
+				return true
+			}
+
+			var event AnthropicStreamEvent
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil {
+				return true
+			}
+
+			switch event.Type {
+			case "message_start":
+				usage.PromptTokens = event.Message.Usage.InputTokens
+			case "message_delta":
+				usage.CompletionTokens = event.Usage.OutputTokens
+			case "message_stop":
+				return false
+			}
+
+			if event.Type == "content_block_delta" && event.Delta.Text != "" {
+				return emit(event.Delta.Text)
+			}
+			return true
+		})
+	}, func() (string, error) {
+		return c.ChatCompletion(context.Background(), prompt)
+	})
+
+	return resultChan, nil
+}
+
+// Check 验证 Anthropic 配置是否有效
+func (c *anthropicClient) Check(ctx context.Context) error {
+	if c.config.Url == "" || c.config.ApiKey == "" {
+		return fmt.Errorf("Anthropic API 配置错误：URL 和 ApiKey 不能为空")
+	}
+
+	if c.config.Model == "" {
+		return fmt.Errorf("Anthropic API 配置错误：Model 不能为空")
+	}
+
+	if c.config.Timeout == 0 {
+		c.config.Timeout = 30 // 默认 30 秒超时
+	}
+
+	return nil
+}
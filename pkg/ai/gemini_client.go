@@ -0,0 +1,183 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+type (
+	geminiClient struct {
+		config *AiConfig
+	}
+
+	// GeminiRequest Gemini generateContent 请求结构
+	GeminiRequest struct {
+		Contents         []GeminiContent         `json:"contents"`
+		GenerationConfig *GeminiGenerationConfig `json:"generationConfig,omitempty"`
+	}
+
+	GeminiContent struct {
+		Role  string       `json:"role,omitempty"`
+		Parts []GeminiPart `json:"parts"`
+	}
+
+	GeminiPart struct {
+		Text string `json:"text"`
+	}
+
+	GeminiGenerationConfig struct {
+		MaxOutputTokens int `json:"maxOutputTokens,omitempty"`
+	}
+
+	// GeminiResponse Gemini 响应结构，文本位于 candidates[].content.parts[].text
+	// 流式响应的每个 SSE 事件也是该结构
+	GeminiResponse struct {
+		Candidates []struct {
+			Content GeminiContent `json:"content"`
+		} `json:"candidates"`
+		// 流式响应中每个事件携带截至当前的累计用量
+		UsageMetadata struct {
+			PromptTokenCount     int `json:"promptTokenCount"`
+			CandidatesTokenCount int `json:"candidatesTokenCount"`
+		} `json:"usageMetadata"`
+	}
+)
+
+// endpoint 获取请求地址
+// 用户配置 generateContent 完整地址，流式请求自动切换为 streamGenerateContent?alt=sse
+// 例如：https://generativelanguage.googleapis.com/v1beta/models/gemini-1.5-pro:generateContent
+// 地址中的 {model} 会替换为实际模型名称
+func (c *geminiClient) endpoint(stream bool) string {
+	url := strings.ReplaceAll(c.config.Url, "{model}", c.config.Model)
+	if !stream {
+		return url
+	}
+
+	url = strings.Replace(url, ":generateContent", ":streamGenerateContent", 1)
+	if !strings.Contains(url, "alt=sse") {
+		if strings.Contains(url, "?") {
+			url += "&alt=sse"
+		} else {
+			url += "?alt=sse"
+		}
+	}
+	return url
+}
+
+// newRequest 构建 Gemini 请求
+func (c *geminiClient) newRequest(ctx context.Context, prompt string, stream bool) (*http.Request, error) {
+	req := GeminiRequest{
+		Contents: []GeminiContent{
+			{Role: "user", Parts: []GeminiPart{{Text: prompt}}},
+		},
+	}
+	if c.config.MaxTokens > 0 {
+		req.GenerationConfig = &GeminiGenerationConfig{MaxOutputTokens: c.config.MaxTokens}
+	}
+
+	return newJSONRequest(ctx, c.config, c.endpoint(stream), req, map[string]string{
+		"x-goog-api-key": c.config.ApiKey,
+	})
+}
+
+// text 拼接响应中第一个候选结果的文本
+func (r GeminiResponse) text() string {
+	if len(r.Candidates) == 0 {
+		return ""
+	}
+
+	var content strings.Builder
+	for _, part := range r.Candidates[0].Content.Parts {
+		content.WriteString(part.Text)
+	}
+	return content.String()
+}
+
+// usage 获取响应中的用量
+func (r GeminiResponse) usage() Usage {
+	return Usage{
+		PromptTokens:     r.UsageMetadata.PromptTokenCount,
+		CompletionTokens: r.UsageMetadata.CandidatesTokenCount,
+	}
+}
+
+// ChatCompletion 调用 Gemini API（非流式）
+func (c *geminiClient) ChatCompletion(ctx context.Context, prompt string) (string, error) {
+	httpReq, err := c.newRequest(ctx, prompt, false)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := doRequest(httpReq, c.config.Timeout, "Gemini")
+	if err != nil {
+		return "", err
+	}
+
+	var respData GeminiResponse
+	if err := json.Unmarshal(body, &respData); err != nil {
+		return "", fmt.Errorf("解析响应失败: %w", err)
+	}
+
+	if content := respData.text(); content != "" {
+		c.config.reportUsage(prompt, content, respData.usage())
+		return content, nil
+	}
+
+	return "", fmt.Errorf("Gemini API 返回空内容")
+}
+
+// StreamCompletion 调用 Gemini API（流式）
+func (c *geminiClient) StreamCompletion(ctx context.Context, prompt string) (<-chan StreamEvent, error) {
+	// 使用后台context而不是外部context，避免请求过期
+	httpReq, err := c.newRequest(context.Background(), prompt, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// 首个片段前失败时回退为非流式调用
+	resultChan := streamWithFallback(ctx, c.config, "Gemini", prompt, func(emit func(string) bool, usage *Usage) error {
+		return doStream(httpReq, c.config.Timeout, func(line string) bool {
+			if !strings.HasPrefix(line, "data: ") {
+				return true
+			}
+
+			var chunk GeminiResponse
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &chunk); err != nil {
+				return true
+			}
+
+			if chunk.UsageMetadata.PromptTokenCount > 0 {
+				*usage = chunk.usage()
+			}
+
+			if content := chunk.text(); content != "" {
+				return emit(content)
+			}
+			return true
+		})
+	}, func() (string, error) {
+		return c.ChatCompletion(context.Background(), prompt)
+	})
+
+	return resultChan, nil
+}
+
+// Check 验证 Gemini 配置是否有效
+func (c *geminiClient) Check(ctx context.Context) error {
+	if c.config.Url == "" || c.config.ApiKey == "" {
+		return fmt.Errorf("Gemini API 配置错误：URL 和 ApiKey 不能为空")
+	}
+
+	if c.config.Model == "" {
+		return fmt.Errorf("Gemini API 配置错误：Model 不能为空")
+	}
+
+	if c.config.Timeout == 0 {
+		c.config.Timeout = 30 // 默认 30 秒超时
+	}
+
+	return nil
+}
@@ -1,189 +1,117 @@
 package ai
 
 import (
-	"bufio"
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
-	"time"
 )
 
 type openaiClient struct {
 	config *AiConfig
 }
 
-// ChatCompletion 调用 OpenAI API（非流式）
-func (c *openaiClient) ChatCompletion(ctx context.Context, prompt string) (string, error) {
-	// 构建 OpenAI 请求体
+// newRequest 构建 OpenAI 请求
+// 直接使用用户配置的完整 URL（不做拼接），用户应在系统设置中填写完整的 API 端点地址
+// 例如：https://api.openai.com/v1/chat/completions
+func (c *openaiClient) newRequest(ctx context.Context, prompt string, stream bool) (*http.Request, error) {
 	req := Request{
 		Model: c.config.Model,
 		Messages: []*Message{
 			{Role: "user", Content: prompt},
 		},
-		Stream:    false,
+		Stream:    stream,
 		MaxTokens: c.config.MaxTokens,
 	}
-
-	body, err := json.Marshal(req)
-	if err != nil {
-		return "", fmt.Errorf("序列化请求体失败: %w", err)
+	if stream {
+		req.StreamOptions = &StreamOptions{IncludeUsage: true}
 	}
 
-	// 直接使用用户配置的完整 URL（不做拼接）
-	// 用户应在系统设置中填写完整的 API 端点地址
-	// 例如：https://api.openai.com/v1/chat/completions
-	url := c.config.Url
+	return newJSONRequest(ctx, c.config, c.config.Url, req, map[string]string{
+		"Authorization": fmt.Sprintf("Bearer %s", c.config.ApiKey),
+	})
+}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+// ChatCompletion 调用 OpenAI API（非流式）
+func (c *openaiClient) ChatCompletion(ctx context.Context, prompt string) (string, error) {
+	httpReq, err := c.newRequest(ctx, prompt, false)
 	if err != nil {
-		return "", fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.ApiKey))
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	// 创建 HTTP 客户端并设置超时
-	httpClient := &http.Client{}
-	if c.config.Timeout > 0 {
-		httpClient.Timeout = time.Duration(c.config.Timeout*3) * time.Second
+		return "", err
 	}
 
-	resp, err := httpClient.Do(httpReq)
+	body, err := doRequest(httpReq, c.config.Timeout, "OpenAI")
 	if err != nil {
-		return "", fmt.Errorf("OpenAI API 调用失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		// 尝试解析错误响应
-		var errResp struct {
-			Error struct {
-				Message string `json:"message"`
-			} `json:"error"`
-		}
-		_ = json.NewDecoder(resp.Body).Decode(&errResp)
-		if errResp.Error.Message != "" {
-			return "", fmt.Errorf("OpenAI API 返回错误 (%d): %s", resp.StatusCode, errResp.Error.Message)
-		}
-		return "", fmt.Errorf("OpenAI API 返回状态码 %d", resp.StatusCode)
+		return "", err
 	}
 
 	// 解析响应
 	var respData Response
-	if err := json.NewDecoder(resp.Body).Decode(&respData); err != nil {
+	if err := json.Unmarshal(body, &respData); err != nil {
 		return "", fmt.Errorf("解析响应失败: %w", err)
 	}
 
 	if len(respData.Choices) > 0 {
-		return respData.Choices[0].Message.Content, nil
+		content := respData.Choices[0].Message.Content
+		c.config.reportUsage(prompt, content, respData.Usage.usage())
+		return content, nil
 	}
 
 	return "", fmt.Errorf("OpenAI API 返回空内容")
 }
 
 // StreamCompletion 调用 OpenAI API（流式）
-func (c *openaiClient) StreamCompletion(ctx context.Context, prompt string) (<-chan string, error) {
-	// 构建 OpenAI 请求体（流式）
-	// 注意：请求体必须在这里构建，不能在 goroutine 内部
-	req := Request{
-		Model: c.config.Model,
-		Messages: []*Message{
-			{Role: "user", Content: prompt},
-		},
-		Stream:    true, // 启用流式
-		MaxTokens: c.config.MaxTokens,
-	}
-
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("序列化请求体失败: %w", err)
-	}
-
-	// 直接使用用户配置的完整 URL（不做拼接）
-	// 用户应在系统设置中填写完整的 API 端点地址
-	// 例如：https://api.openai.com/v1/chat/completions
-	url := c.config.Url
-
+func (c *openaiClient) StreamCompletion(ctx context.Context, prompt string) (<-chan StreamEvent, error) {
 	// 使用后台context而不是外部context，避免请求过期
 	bgCtx := context.Background()
-	httpReq, err := http.NewRequestWithContext(bgCtx, "POST", url, bytes.NewReader(body))
+	httpReq, err := c.newRequest(bgCtx, prompt, true)
 	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
+		return nil, err
 	}
 
-	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.config.ApiKey))
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resultChan := make(chan string, 10)
-
-	go func() {
-		defer close(resultChan)
-
-		// 创建 HTTP 客户端并设置超时
-		httpClient := &http.Client{}
-		if c.config.Timeout > 0 {
-			httpClient.Timeout = time.Duration(c.config.Timeout*3) * time.Second
-		}
-
-		resp, err := httpClient.Do(httpReq)
-		if err != nil {
-			return
-		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			var errResp struct {
-				Error struct {
-					Message string `json:"message"`
-				} `json:"error"`
-			}
-			_ = json.NewDecoder(resp.Body).Decode(&errResp)
-			return
-		}
-
-		// 处理 SSE 流式响应
-		scanner := bufio.NewScanner(resp.Body)
-		for scanner.Scan() {
-			line := scanner.Text()
-
+	// 处理 SSE 流式响应，首个片段前失败时回退为非流式调用
+	resultChan := streamWithFallback(ctx, c.config, "OpenAI", prompt, func(emit func(string) bool, usage *Usage) error {
+		return doStream(httpReq, c.config.Timeout, func(line string) bool {
 			// SSE 格式：data: {...}
 			if !strings.HasPrefix(line, "data: ") {
-				continue
+				return true
 			}
 
 			jsonData := strings.TrimPrefix(line, "data: ")
 
 			// [DONE] 标志表示流结束
 			if jsonData == "[DONE]" {
-				break
+				return false
 			}
 
 			// 解析 StreamChunk
 			var chunk StreamChunk
 			if err := json.Unmarshal([]byte(jsonData), &chunk); err != nil {
-				continue
+				return true
+			}
+
+			if chunk.Usage != nil {
+				*usage = chunk.Usage.usage()
 			}
 
 			// 提取内容：choices[0].delta.content
-			if len(chunk.Choices) > 0 {
-				content := chunk.Choices[0].Delta.Content
-				if content != "" {
-					select {
-					case <-ctx.Done():
-						return
-					case resultChan <- content:
-					}
-				}
+			if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+				return emit(chunk.Choices[0].Delta.Content)
 			}
-		}
-	}()
+			return true
+		})
+	}, func() (string, error) {
+		return c.ChatCompletion(bgCtx, prompt)
+	})
 
 	return resultChan, nil
 }
 
+// usage 转换为通用用量结构
+func (u OpenAIUsage) usage() Usage {
+	return Usage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens}
+}
+
 // Check 验证 OpenAI 配置是否有效
 func (c *openaiClient) Check(ctx context.Context) error {
 	if c.config.Url == "" || c.config.ApiKey == "" {
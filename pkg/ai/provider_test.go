@@ -0,0 +1,219 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// capturedRequest 测试服务端记录的请求
+type capturedRequest struct {
+	path   string
+	query  string
+	header http.Header
+	body   map[string]any
+}
+
+// stubResponse 测试服务端返回的状态码与响应体
+type stubResponse struct {
+	status int
+	body   string
+}
+
+// newProviderServer 按顺序返回 responses 中的状态码与响应体，并记录每次请求
+func newProviderServer(t *testing.T, responses ...stubResponse) (*httptest.Server, *[]capturedRequest) {
+	t.Helper()
+	var (
+		calls    atomic.Int64
+		captured []capturedRequest
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, _ := io.ReadAll(r.Body)
+		var body map[string]any
+		_ = json.Unmarshal(raw, &body)
+		captured = append(captured, capturedRequest{path: r.URL.Path, query: r.URL.RawQuery, header: r.Header.Clone(), body: body})
+
+		resp := responses[min(int(calls.Add(1))-1, len(responses)-1)]
+		w.WriteHeader(resp.status)
+		_, _ = io.WriteString(w, resp.body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &captured
+}
+
+func reply(status int, body string) stubResponse {
+	return stubResponse{status: status, body: body}
+}
+
+func TestAnthropicChatCompletionMapping(t *testing.T) {
+	srv, captured := newProviderServer(t, reply(http.StatusOK, `{
+		"id": "msg_1",
+		"content": [
+			{"type": "text", "text": "CPU 使用率"},
+			{"type": "tool_use", "text": "ignored"},
+			{"type": "text", "text": "持续升高"}
+		]
+	}`))
+
+	client := &anthropicClient{config: &AiConfig{
+		Url:     srv.URL + "/v1/messages",
+		ApiKey:  "sk-ant",
+		Model:   "claude-test",
+		Headers: map[string]string{"X-Gateway-Token": "gw"},
+	}}
+	got, err := client.ChatCompletion(context.Background(), "分析告警")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "CPU 使用率持续升高" {
+		t.Fatalf("got %q, want the text blocks joined", got)
+	}
+
+	req := (*captured)[0]
+	if req.path != "/v1/messages" {
+		t.Fatalf("path %s", req.path)
+	}
+	for k, want := range map[string]string{
+		"x-api-key":         "sk-ant",
+		"anthropic-version": anthropicVersion,
+		"Content-Type":      "application/json",
+		"X-Gateway-Token":   "gw",
+	} {
+		if v := req.header.Get(k); v != want {
+			t.Fatalf("header %s = %q, want %q", k, v, want)
+		}
+	}
+	if req.header.Get("Authorization") != "" {
+		t.Fatal("anthropic must not send a bearer token")
+	}
+
+	if req.body["model"] != "claude-test" || req.body["max_tokens"] != float64(anthropicDefaultMaxTokens) {
+		t.Fatalf("body %v, want model and default max_tokens", req.body)
+	}
+	if _, ok := req.body["stream"]; ok {
+		t.Fatalf("non-streaming request must omit stream, body %v", req.body)
+	}
+	messages, _ := req.body["messages"].([]any)
+	if len(messages) != 1 || messages[0].(map[string]any)["content"] != "分析告警" || messages[0].(map[string]any)["role"] != "user" {
+		t.Fatalf("messages %v", req.body["messages"])
+	}
+}
+
+func TestGeminiChatCompletionMapping(t *testing.T) {
+	srv, captured := newProviderServer(t, reply(http.StatusOK, `{
+		"candidates": [
+			{"content": {"role": "model", "parts": [{"text": "内存"}, {"text": "泄漏"}]}},
+			{"content": {"role": "model", "parts": [{"text": "second candidate"}]}}
+		]
+	}`))
+
+	client := &geminiClient{config: &AiConfig{
+		Url:       srv.URL + "/v1beta/models/{model}:generateContent",
+		ApiKey:    "g-key",
+		Model:     "gemini-test",
+		MaxTokens: 256,
+	}}
+	got, err := client.ChatCompletion(context.Background(), "分析告警")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "内存泄漏" {
+		t.Fatalf("got %q, want the first candidate's parts joined", got)
+	}
+
+	req := (*captured)[0]
+	if req.path != "/v1beta/models/gemini-test:generateContent" || req.query != "" {
+		t.Fatalf("path %s?%s, want the model substituted", req.path, req.query)
+	}
+	if req.header.Get("x-goog-api-key") != "g-key" {
+		t.Fatalf("x-goog-api-key = %q", req.header.Get("x-goog-api-key"))
+	}
+
+	contents, _ := req.body["contents"].([]any)
+	if len(contents) != 1 {
+		t.Fatalf("contents %v", req.body["contents"])
+	}
+	parts := contents[0].(map[string]any)["parts"].([]any)
+	if parts[0].(map[string]any)["text"] != "分析告警" {
+		t.Fatalf("parts %v", parts)
+	}
+	if cfg, _ := req.body["generationConfig"].(map[string]any); cfg["maxOutputTokens"] != float64(256) {
+		t.Fatalf("generationConfig %v", req.body["generationConfig"])
+	}
+}
+
+func TestGeminiStreamEndpoint(t *testing.T) {
+	client := &geminiClient{config: &AiConfig{Url: "https://g.example/v1beta/models/{model}:generateContent?foo=1", Model: "m"}}
+	if got := client.endpoint(true); got != "https://g.example/v1beta/models/m:streamGenerateContent?foo=1&alt=sse" {
+		t.Fatalf("got %s", got)
+	}
+}
+
+func TestProviderErrorsAndRetry(t *testing.T) {
+	tests := []struct {
+		name      string
+		responses []stubResponse
+		wantCalls int
+		wantErr   string
+		want      string
+	}{
+		{
+			name:      "5xx 后重试成功",
+			responses: []stubResponse{reply(503, `{"error":{"message":"overloaded"}}`), reply(200, `{"content":[{"type":"text","text":"ok"}]}`)},
+			wantCalls: 2,
+			want:      "ok",
+		},
+		{
+			name:      "429 重试至上限",
+			responses: []stubResponse{reply(429, `{"error":{"message":"rate limited"}}`)},
+			wantCalls: requestMaxAttempts,
+			wantErr:   "Anthropic API 返回错误 (429): rate limited",
+		},
+		{
+			name:      "4xx 不重试",
+			responses: []stubResponse{reply(400, `{"error":{"message":"invalid model"}}`)},
+			wantCalls: 1,
+			wantErr:   "Anthropic API 返回错误 (400): invalid model",
+		},
+		{
+			name:      "响应体无法解析",
+			responses: []stubResponse{reply(200, `not json`)},
+			wantCalls: 1,
+			wantErr:   "解析响应失败",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, captured := newProviderServer(t, tt.responses...)
+			client := &anthropicClient{config: &AiConfig{Url: srv.URL, ApiKey: "k", Model: "m"}}
+
+			got, err := client.ChatCompletion(context.Background(), "p")
+			if len(*captured) != tt.wantCalls {
+				t.Fatalf("got %d calls, want %d", len(*captured), tt.wantCalls)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("got %q err %v, want %q", got, err, tt.want)
+			}
+
+			// 重试时请求体完整重发
+			for _, req := range *captured {
+				if req.body["model"] != "m" {
+					t.Fatalf("retried request lost its body: %v", req.body)
+				}
+			}
+		})
+	}
+}
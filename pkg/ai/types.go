@@ -10,19 +10,28 @@ type (
 		// ChatCompletion returns the completion of the given input text.
 		ChatCompletion(context.Context, string) (string, error)
 		// StreamCompletion returns a channel that streams the completion of the given input text.
-		StreamCompletion(context.Context, string) (<-chan string, error)
+		// An event with a non-nil Err is terminal and the channel is closed after it.
+		StreamCompletion(context.Context, string) (<-chan StreamEvent, error)
 		// Check checks the health of the AI chatbot client.
 		Check(context.Context) error
 	}
 
+	// StreamEvent 流式输出事件，Err 非空时为终止事件，之后通道关闭
+	StreamEvent struct {
+		Content string
+		Err     error
+	}
+
 	AiConfig struct {
-		Provider  string // dify | openai，默认 dify
+		Provider  string // dify | openai | anthropic | gemini，默认 dify
 		Url       string
 		ApiKey    string
 		Model     string
 		Timeout   int
 		Stream    bool
 		MaxTokens int
+		Headers   map[string]string // 自定义请求头，例如经网关代理时附加的鉴权信息
+		OnUsage   func(Usage)       // 每次调用完成后回调本次 token 用量，供上层统计费用
 	}
 
 	// OpenAI 格式请求
@@ -32,6 +41,18 @@ type (
 		Stream      bool       `json:"stream,omitempty"`
 		MaxTokens   int        `json:"max_tokens,omitempty"`
 		Temperature float64    `json:"temperature,omitempty"`
+		// 流式请求时要求在最后一个片段中返回用量
+		StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+	}
+
+	StreamOptions struct {
+		IncludeUsage bool `json:"include_usage"`
+	}
+
+	// OpenAIUsage OpenAI 格式的用量
+	OpenAIUsage struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
 	}
 
 	// Dify 格式请求
@@ -59,6 +80,8 @@ type (
 				Content string `json:"content"`
 			} `json:"delta"`
 		} `json:"choices"`
+		// 仅最后一个片段携带用量，且该片段的 choices 为空
+		Usage *OpenAIUsage `json:"usage"`
 	}
 
 	// Response OpenAI 格式响应结构
@@ -69,6 +92,7 @@ type (
 				Content string `json:"content"`
 			} `json:"message"`
 		} `json:"choices"`
+		Usage OpenAIUsage `json:"usage"`
 		Error struct {
 			Message string `json:"message"`
 		} `json:"error"`
@@ -0,0 +1,48 @@
+package ai
+
+import "unicode"
+
+// Usage 单次调用的 token 用量
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	// Estimated 为 true 表示 Provider 未返回用量，至少有一项按文本估算
+	Estimated bool
+}
+
+// reportUsage 回调本次调用的用量，Provider 未返回的项按文本估算
+func (c *AiConfig) reportUsage(prompt, completion string, usage Usage) {
+	if c.OnUsage == nil {
+		return
+	}
+
+	if usage.PromptTokens == 0 {
+		usage.PromptTokens = estimateTokens(prompt)
+		usage.Estimated = true
+	}
+	if usage.CompletionTokens == 0 && completion != "" {
+		usage.CompletionTokens = estimateTokens(completion)
+		usage.Estimated = true
+	}
+
+	c.OnUsage(usage)
+}
+
+// estimateTokens 粗略估算文本的 token 数
+// 各模型分词器不同，这里按常见 BPE 分词器的经验值估算：中日韩字符约 1 字 1 token，其余字符约 4 字符 1 token
+func estimateTokens(text string) int {
+	if text == "" {
+		return 0
+	}
+
+	var cjk, other int
+	for _, r := range text {
+		if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+
+	return cjk + (other+3)/4
+}
@@ -0,0 +1,185 @@
+package ai
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"a", 1},
+		{"abcd", 1},
+		{"abcde", 2},
+		{"磁盘使用率", 5},
+		{"CPU 使用率", 4},
+	}
+
+	for _, tt := range tests {
+		if got := estimateTokens(tt.text); got != tt.want {
+			t.Fatalf("estimateTokens(%q) got %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+// recordUsage 设置 OnUsage 回调并返回收到的用量
+func recordUsage(config *AiConfig) *[]Usage {
+	var usages []Usage
+	config.OnUsage = func(u Usage) {
+		usages = append(usages, u)
+	}
+	return &usages
+}
+
+func TestChatCompletionReportsUsage(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		client func(config *AiConfig) AiClient
+		want   Usage
+	}{
+		{
+			name:   "OpenAI",
+			body:   `{"choices":[{"message":{"content":"ok"}}],"usage":{"prompt_tokens":12,"completion_tokens":3}}`,
+			client: func(config *AiConfig) AiClient { return &openaiClient{config: config} },
+			want:   Usage{PromptTokens: 12, CompletionTokens: 3},
+		},
+		{
+			name:   "Anthropic",
+			body:   `{"content":[{"type":"text","text":"ok"}],"usage":{"input_tokens":20,"output_tokens":5}}`,
+			client: func(config *AiConfig) AiClient { return &anthropicClient{config: config} },
+			want:   Usage{PromptTokens: 20, CompletionTokens: 5},
+		},
+		{
+			name:   "Gemini",
+			body:   `{"candidates":[{"content":{"parts":[{"text":"ok"}]}}],"usageMetadata":{"promptTokenCount":8,"candidatesTokenCount":2}}`,
+			client: func(config *AiConfig) AiClient { return &geminiClient{config: config} },
+			want:   Usage{PromptTokens: 8, CompletionTokens: 2},
+		},
+		{
+			name: "Dify",
+			body: "data: {\"event\":\"message\",\"answer\":\"o\"}\n\n" +
+				"data: {\"event\":\"message\",\"answer\":\"k\"}\n\n" +
+				"data: {\"event\":\"message_end\",\"metadata\":{\"usage\":{\"prompt_tokens\":30,\"completion_tokens\":7}}}\n\n",
+			client: func(config *AiConfig) AiClient { return &difyClient{config: config} },
+			want:   Usage{PromptTokens: 30, CompletionTokens: 7},
+		},
+		{
+			name:   "缺少用量时估算",
+			body:   `{"choices":[{"message":{"content":"磁盘使用率"}}]}`,
+			client: func(config *AiConfig) AiClient { return &openaiClient{config: config} },
+			want:   Usage{PromptTokens: 2, CompletionTokens: 5, Estimated: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, _ := newProviderServer(t, reply(http.StatusOK, tt.body))
+			config := &AiConfig{Url: srv.URL, ApiKey: "k", Model: "m", Timeout: 1}
+			usages := recordUsage(config)
+
+			got, err := tt.client(config).ChatCompletion(context.Background(), "analyze")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got == "" {
+				t.Fatal("empty completion")
+			}
+			if len(*usages) != 1 || (*usages)[0] != tt.want {
+				t.Fatalf("usage got %+v, want [%+v]", *usages, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamCompletionReportsUsage(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		client func(config *AiConfig) AiClient
+		want   Usage
+	}{
+		{
+			name: "OpenAI",
+			body: "data: {\"choices\":[{\"delta\":{\"content\":\"o\"}}]}\n\n" +
+				"data: {\"choices\":[{\"delta\":{\"content\":\"k\"}}]}\n\n" +
+				"data: {\"choices\":[],\"usage\":{\"prompt_tokens\":12,\"completion_tokens\":3}}\n\n" +
+				"data: [DONE]\n\n",
+			client: func(config *AiConfig) AiClient { return &openaiClient{config: config} },
+			want:   Usage{PromptTokens: 12, CompletionTokens: 3},
+		},
+		{
+			name: "Anthropic",
+			body: "data: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":20,\"output_tokens\":1}}}\n\n" +
+				"data: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"ok\"}}\n\n" +
+				"data: {\"type\":\"message_delta\",\"usage\":{\"output_tokens\":5}}\n\n" +
+				"data: {\"type\":\"message_stop\"}\n\n",
+			client: func(config *AiConfig) AiClient { return &anthropicClient{config: config} },
+			want:   Usage{PromptTokens: 20, CompletionTokens: 5},
+		},
+		{
+			name: "Gemini",
+			body: "data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"o\"}]}}],\"usageMetadata\":{\"promptTokenCount\":8,\"candidatesTokenCount\":1}}\n\n" +
+				"data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"k\"}]}}],\"usageMetadata\":{\"promptTokenCount\":8,\"candidatesTokenCount\":2}}\n\n",
+			client: func(config *AiConfig) AiClient { return &geminiClient{config: config} },
+			want:   Usage{PromptTokens: 8, CompletionTokens: 2},
+		},
+		{
+			name: "Dify",
+			body: "data: {\"event\":\"message\",\"answer\":\"ok\"}\n\n" +
+				"data: {\"event\":\"message_end\",\"metadata\":{\"usage\":{\"prompt_tokens\":30,\"completion_tokens\":7}}}\n\n",
+			client: func(config *AiConfig) AiClient { return &difyClient{config: config} },
+			want:   Usage{PromptTokens: 30, CompletionTokens: 7},
+		},
+		{
+			name:   "缺少用量时按已输出内容估算",
+			body:   "data: {\"choices\":[{\"delta\":{\"content\":\"磁盘使用率\"}}]}\n\ndata: [DONE]\n\n",
+			client: func(config *AiConfig) AiClient { return &openaiClient{config: config} },
+			want:   Usage{PromptTokens: 2, CompletionTokens: 5, Estimated: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, captured := newProviderServer(t, reply(http.StatusOK, tt.body))
+			config := &AiConfig{Url: srv.URL, ApiKey: "k", Model: "m", Timeout: 1}
+			usages := recordUsage(config)
+
+			ch, err := tt.client(config).StreamCompletion(context.Background(), "analyze")
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, event := range collectStream(t, ch) {
+				if event.Err != nil {
+					t.Fatal(event.Err)
+				}
+			}
+
+			if len(*captured) != 1 {
+				t.Fatalf("got %d requests, want a single streaming request", len(*captured))
+			}
+			if len(*usages) != 1 || (*usages)[0] != tt.want {
+				t.Fatalf("usage got %+v, want [%+v]", *usages, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIStreamRequestsUsage(t *testing.T) {
+	srv, captured := newProviderServer(t, reply(http.StatusOK, "data: {\"choices\":[{\"delta\":{\"content\":\"ok\"}}]}\n\ndata: [DONE]\n\n"))
+
+	ch, err := newTestOpenAIClient(srv.URL).StreamCompletion(context.Background(), "analyze")
+	if err != nil {
+		t.Fatal(err)
+	}
+	collectStream(t, ch)
+
+	options, _ := (*captured)[0].body["stream_options"].(map[string]any)
+	if options["include_usage"] != true {
+		t.Fatalf("stream_options got %v, want include_usage", (*captured)[0].body["stream_options"])
+	}
+}
@@ -72,14 +72,15 @@ func NewDBClient(config DBConfig) *gorm.DB {
 		&models.Comment{},
 		&models.ExporterMonitorConfig{},
 		&models.ExporterReportSchedule{},
-		&models.ExporterInspection{},       // 新增: 巡检记录主表
-		&models.ExporterInspectionDetail{}, // 新增: 巡检明细表
-		&models.ProcessTrace{},             // 新增: 处理流程追踪表
-		&models.ProcessOperationLog{},      // 新增: 处理操作日志表
-		&models.ThirdPartyWebhook{},        // 新增: 第三方Webhook配置表
-		&models.ThirdPartyAlert{},          // 新增: 第三方告警记录表
-		&models.ConsulTarget{},             // 新增: Consul目标追踪表
-		&models.ConsulTargetOfflineLog{},   // 新增: Consul注销历史记录表
+		&models.ExporterInspection{},        // 新增: 巡检记录主表
+		&models.ExporterInspectionDetail{},  // 新增: 巡检明细表
+		&models.ProcessTrace{},              // 新增: 处理流程追踪表
+		&models.ProcessOperationLog{},       // 新增: 处理操作日志表
+		&models.ProcessOperationLogRollup{}, // 新增: 处理操作日志按天汇总表
+		&models.ThirdPartyWebhook{},         // 新增: 第三方Webhook配置表
+		&models.ThirdPartyAlert{},           // 新增: 第三方告警记录表
+		&models.ConsulTarget{},              // 新增: Consul目标追踪表
+		&models.ConsulTargetOfflineLog{},    // 新增: Consul注销历史记录表
 	)
 	if err != nil {
 		logc.Error(context.Background(), err.Error())
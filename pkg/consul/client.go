@@ -3,9 +3,11 @@ package consul
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	consulapi "github.com/hashicorp/consul/api"
+	"golang.org/x/sync/errgroup"
 )
 
 // Client Consul 客户端封装
@@ -72,6 +74,7 @@ func (c *Client) GetServices(ctx context.Context) (map[string]*consulapi.AgentSe
 
 // ServiceInstance 服务实例信息
 type ServiceInstance struct {
+	Node        string // 所在节点名，仅从服务目录获取时有值
 	ServiceID   string
 	ServiceName string
 	Address     string
@@ -255,7 +258,8 @@ func (c *Client) UpdateServiceMeta(ctx context.Context, serviceID string, meta m
 }
 
 // RegisterService 注册新服务
-func (c *Client) RegisterService(ctx context.Context, serviceID, serviceName, address string, port int, tags []string, meta map[string]string) error {
+// check 不为空时同时注册健康检查，由 Consul Agent 执行；为空时服务没有健康检查
+func (c *Client) RegisterService(ctx context.Context, serviceID, serviceName, address string, port int, tags []string, meta map[string]string, check *HealthCheckConfig) error {
 	// 构建服务注册请求
 	reg := &consulapi.AgentServiceRegistration{
 		ID:      serviceID,
@@ -265,6 +269,9 @@ func (c *Client) RegisterService(ctx context.Context, serviceID, serviceName, ad
 		Tags:    tags,
 		Meta:    meta,
 	}
+	if check != nil {
+		reg.Check = check.toAgentCheck(address, port)
+	}
 
 	// 注册服务
 	err := c.client.Agent().ServiceRegister(reg)
@@ -299,6 +306,107 @@ func (c *Client) GetServiceByID(ctx context.Context, serviceID string) (*Service
 	return instance, nil
 }
 
+// GetAllServiceDetails 一次性获取当前 Agent 上所有服务实例的完整信息（地址、端口、Tags、Meta），按 ServiceID 索引
+// 与 RegisterService 写入的范围一致，用于批量同步，避免逐个服务调用 GetServiceByID
+func (c *Client) GetAllServiceDetails(ctx context.Context) (map[string]ServiceInstance, error) {
+	services, err := c.client.Agent().ServicesWithFilterOpts("", (&consulapi.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("获取 Consul 服务详情失败: %w", err)
+	}
+
+	details := make(map[string]ServiceInstance, len(services))
+	for serviceID, service := range services {
+		details[serviceID] = ServiceInstance{
+			ServiceID:   service.ID,
+			ServiceName: service.Service,
+			Address:     service.Address,
+			Port:        service.Port,
+			Tags:        service.Tags,
+			Meta:        service.Meta,
+		}
+	}
+
+	return details, nil
+}
+
+// catalogFetchConcurrency 拉取服务目录实例详情的并发数
+const catalogFetchConcurrency = 8
+
+// consulServiceName Consul Server 自身在服务目录中注册的服务名，同步时不作为目标
+const consulServiceName = "consul"
+
+// GetCatalogServiceDetails 从集群服务目录获取所有节点上的服务实例，按 ServiceID 索引
+// 服务目录没有一次返回全部实例的接口：先获取服务名列表，再按服务名各请求一次（并发数受限）
+// ServiceID 只在节点内唯一，多个节点存在相同 ServiceID 时保留节点名最小的实例，保证结果稳定
+func (c *Client) GetCatalogServiceDetails(ctx context.Context) (map[string]ServiceInstance, error) {
+	opts := (&consulapi.QueryOptions{}).WithContext(ctx)
+	names, _, err := c.client.Catalog().Services(opts)
+	if err != nil {
+		return nil, fmt.Errorf("获取 Consul 服务目录失败: %w", err)
+	}
+
+	var (
+		mu      sync.Mutex
+		details = make(map[string]ServiceInstance)
+	)
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(catalogFetchConcurrency)
+	for name := range names {
+		if name == consulServiceName {
+			continue
+		}
+
+		g.Go(func() error {
+			instances, err := c.GetCatalogServiceInstances(gctx, name)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, instance := range instances {
+				if existing, exists := details[instance.ServiceID]; !exists || instance.Node < existing.Node {
+					details[instance.ServiceID] = instance
+				}
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return details, nil
+}
+
+// GetCatalogServiceInstances 从服务目录获取指定服务在所有节点上的实例，服务未设置地址时使用节点地址
+func (c *Client) GetCatalogServiceInstances(ctx context.Context, serviceName string) ([]ServiceInstance, error) {
+	opts := (&consulapi.QueryOptions{}).WithContext(ctx)
+	entries, _, err := c.client.Catalog().Service(serviceName, "", opts)
+	if err != nil {
+		return nil, fmt.Errorf("获取服务 %s 的目录信息失败: %w", serviceName, err)
+	}
+
+	instances := make([]ServiceInstance, 0, len(entries))
+	for _, entry := range entries {
+		address := entry.ServiceAddress
+		if address == "" {
+			address = entry.Address
+		}
+		instances = append(instances, ServiceInstance{
+			Node:        entry.Node,
+			ServiceID:   entry.ServiceID,
+			ServiceName: entry.ServiceName,
+			Address:     address,
+			Port:        entry.ServicePort,
+			Tags:        entry.ServiceTags,
+			Meta:        entry.ServiceMeta,
+		})
+	}
+
+	return instances, nil
+}
+
 // FilterServiceInstancesByTag 从服务实例列表中按标签过滤
 func (c *Client) FilterServiceInstancesByTag(instances []ServiceInstance, tag string) []ServiceInstance {
 	var filtered []ServiceInstance
@@ -0,0 +1,134 @@
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newCatalogServer 模拟 Consul 服务目录和 Agent 服务接口
+func newCatalogServer(t *testing.T, catalog map[string][]map[string]interface{}) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(catalogHandler(catalog))
+}
+
+func catalogHandler(catalog map[string][]map[string]interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Consul-Index", "1")
+		switch {
+		case r.URL.Path == "/v1/catalog/services":
+			names := make(map[string][]string)
+			for name := range catalog {
+				names[name] = nil
+			}
+			_ = json.NewEncoder(w).Encode(names)
+		case r.URL.Path == "/v1/agent/services":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"node-1": map[string]interface{}{"ID": "node-1", "Service": "node-exporter", "Address": "10.0.0.1", "Port": 9100, "Tags": []string{"prod"}},
+			})
+		case strings.HasPrefix(r.URL.Path, "/v1/catalog/service/"):
+			_ = json.NewEncoder(w).Encode(catalog[strings.TrimPrefix(r.URL.Path, "/v1/catalog/service/")])
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+func TestGetCatalogServiceDetails(t *testing.T) {
+	server := newCatalogServer(t, map[string][]map[string]interface{}{
+		"node-exporter": {
+			{"Node": "n1", "Address": "10.0.0.1", "ServiceID": "node-1", "ServiceName": "node-exporter", "ServiceAddress": "", "ServicePort": 9100},
+			{"Node": "n2", "Address": "10.0.0.2", "ServiceID": "node-2", "ServiceName": "node-exporter", "ServiceAddress": "192.168.0.2", "ServicePort": 9100, "ServiceTags": []string{"prod"}},
+		},
+		"mysql": {
+			{"Node": "n3", "Address": "10.0.0.3", "ServiceID": "mysql-1", "ServiceName": "mysql", "ServicePort": 9104, "ServiceMeta": map[string]string{"env": "prod"}},
+		},
+		"consul": {
+			{"Node": "n1", "Address": "10.0.0.1", "ServiceID": "consul", "ServiceName": "consul", "ServicePort": 8300},
+		},
+	})
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{Address: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient err: %v", err)
+	}
+
+	details, err := client.GetCatalogServiceDetails(context.Background())
+	if err != nil {
+		t.Fatalf("GetCatalogServiceDetails err: %v", err)
+	}
+
+	if len(details) != 3 {
+		t.Fatalf("实例数 = %d, want 3（不包含 consul 自身）: %+v", len(details), details)
+	}
+	if got := details["node-1"]; got.Address != "10.0.0.1" || got.Port != 9100 {
+		t.Fatalf("未设置服务地址时应使用节点地址, got %+v", got)
+	}
+	if got := details["node-2"]; got.Address != "192.168.0.2" || len(got.Tags) != 1 {
+		t.Fatalf("node-2 = %+v", got)
+	}
+	if got := details["mysql-1"]; got.ServiceName != "mysql" || got.Meta["env"] != "prod" {
+		t.Fatalf("mysql-1 = %+v", got)
+	}
+}
+
+func TestGetCatalogServiceDetailsDedupByNode(t *testing.T) {
+	// 同一 ServiceID 注册在多个节点上，且各服务的返回顺序不同
+	server := newCatalogServer(t, map[string][]map[string]interface{}{
+		"node-exporter": {
+			{"Node": "n3", "Address": "10.0.0.3", "ServiceID": "shared", "ServiceName": "node-exporter", "ServicePort": 9103},
+			{"Node": "n1", "Address": "10.0.0.1", "ServiceID": "shared", "ServiceName": "node-exporter", "ServicePort": 9101},
+		},
+		"blackbox": {
+			{"Node": "n2", "Address": "10.0.0.2", "ServiceID": "shared", "ServiceName": "blackbox", "ServicePort": 9102},
+		},
+	})
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{Address: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient err: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		details, err := client.GetCatalogServiceDetails(context.Background())
+		if err != nil {
+			t.Fatalf("GetCatalogServiceDetails err: %v", err)
+		}
+		if got := details["shared"]; got.Node != "n1" || got.Port != 9101 {
+			t.Fatalf("重复 ServiceID 应保留节点名最小的实例, got %+v", got)
+		}
+	}
+}
+
+func TestGetAllServiceDetailsUsesAgent(t *testing.T) {
+	var catalogCalls int
+	handler := catalogHandler(nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/v1/catalog/") {
+			catalogCalls++
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(ClientConfig{Address: server.URL})
+	if err != nil {
+		t.Fatalf("NewClient err: %v", err)
+	}
+
+	details, err := client.GetAllServiceDetails(context.Background())
+	if err != nil {
+		t.Fatalf("GetAllServiceDetails err: %v", err)
+	}
+	if len(details) != 1 || details["node-1"].Port != 9100 || details["node-1"].Address != "10.0.0.1" {
+		t.Fatalf("details = %+v", details)
+	}
+	if catalogCalls != 0 {
+		t.Fatalf("Agent 范围不应查询服务目录, got %d catalog calls", catalogCalls)
+	}
+}
@@ -0,0 +1,116 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+// 健康检查类型
+const (
+	HealthCheckHTTP = "http"
+	HealthCheckTCP  = "tcp"
+	HealthCheckTTL  = "ttl"
+)
+
+// 健康检查默认参数
+const (
+	defaultCheckInterval = "10s"
+	defaultCheckTimeout  = "5s"
+	defaultCheckTTL      = "30s"
+)
+
+// HealthCheckConfig 服务注册时附带的健康检查配置，由 Consul Agent 实际执行检查
+type HealthCheckConfig struct {
+	Type     string `json:"type"`     // 检查类型: http / tcp / ttl
+	Endpoint string `json:"endpoint"` // http 为检查路径或完整 URL，tcp 为 host:port，未填写时使用服务地址和端口
+	Interval string `json:"interval"` // 检查间隔，如 "10s"，默认 10s（ttl 类型为 TTL 时长，默认 30s）
+	Timeout  string `json:"timeout"`  // 检查超时，如 "5s"，默认 5s，ttl 类型不使用
+}
+
+// Validate 校验健康检查配置
+func (h *HealthCheckConfig) Validate() error {
+	switch strings.ToLower(h.Type) {
+	case HealthCheckHTTP, HealthCheckTCP, HealthCheckTTL:
+	default:
+		return fmt.Errorf("不支持的健康检查类型: %s，可选值: http/tcp/ttl", h.Type)
+	}
+
+	for _, d := range []string{h.Interval, h.Timeout} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.ParseDuration(d); err != nil {
+			return fmt.Errorf("健康检查时长格式不正确: %s", d)
+		}
+	}
+
+	return nil
+}
+
+// toAgentCheck 转换为 Consul Agent 的检查定义
+func (h *HealthCheckConfig) toAgentCheck(address string, port int) *consulapi.AgentServiceCheck {
+	interval := h.Interval
+	if interval == "" {
+		interval = defaultCheckInterval
+	}
+	timeout := h.Timeout
+	if timeout == "" {
+		timeout = defaultCheckTimeout
+	}
+	hostPort := net.JoinHostPort(address, strconv.Itoa(port))
+
+	switch strings.ToLower(h.Type) {
+	case HealthCheckHTTP:
+		endpoint := h.Endpoint
+		if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+			if endpoint != "" && !strings.HasPrefix(endpoint, "/") {
+				endpoint = "/" + endpoint
+			}
+			endpoint = "http://" + hostPort + endpoint
+		}
+		return &consulapi.AgentServiceCheck{HTTP: endpoint, Interval: interval, Timeout: timeout}
+	case HealthCheckTCP:
+		endpoint := h.Endpoint
+		if endpoint == "" {
+			endpoint = hostPort
+		}
+		return &consulapi.AgentServiceCheck{TCP: endpoint, Interval: interval, Timeout: timeout}
+	default:
+		ttl := h.Interval
+		if ttl == "" {
+			ttl = defaultCheckTTL
+		}
+		return &consulapi.AgentServiceCheck{TTL: ttl}
+	}
+}
+
+// GetServiceCheckStatus 获取服务在本地 Agent 上的健康检查状态
+// 存在多个检查时取最差的状态，没有检查时返回 "no checks"
+func (c *Client) GetServiceCheckStatus(ctx context.Context, serviceID string) (string, error) {
+	opts := (&consulapi.QueryOptions{}).WithContext(ctx)
+	checks, err := c.client.Agent().ChecksWithFilterOpts(fmt.Sprintf("ServiceID == %q", serviceID), opts)
+	if err != nil {
+		return "", fmt.Errorf("获取服务 %s 健康检查状态失败: %w", serviceID, err)
+	}
+
+	if len(checks) == 0 {
+		return "no checks", nil
+	}
+
+	status := consulapi.HealthPassing
+	for _, check := range checks {
+		switch check.Status {
+		case consulapi.HealthCritical:
+			return consulapi.HealthCritical, nil
+		case consulapi.HealthWarning:
+			status = consulapi.HealthWarning
+		}
+	}
+	return status, nil
+}
@@ -0,0 +1,76 @@
+package consul
+
+import (
+	"context"
+	"time"
+
+	consulapi "github.com/hashicorp/consul/api"
+)
+
+const (
+	// catalogWatchWaitTime 阻塞查询的最长等待时间，超时后无变化也会返回
+	catalogWatchWaitTime = 5 * time.Minute
+	// catalogWatchMinInterval 两次变更回调之间的最小间隔，避免频繁注册/注销时反复同步
+	catalogWatchMinInterval = 5 * time.Second
+	// catalogWatchMinBackoff / catalogWatchMaxBackoff Consul 不可达时的重试退避区间
+	catalogWatchMinBackoff = time.Second
+	catalogWatchMaxBackoff = 2 * time.Minute
+)
+
+// WatchCatalog 基于阻塞查询监听服务目录变化，目录索引变化时调用 onChange
+// Agent 上的服务变更会同步到服务目录，因此目录索引变化同时覆盖 agent 和 catalog 两种同步范围
+// 阻塞直到 ctx 结束；请求失败时按指数退避重试，避免 Consul 不可达时空转
+func (c *Client) WatchCatalog(ctx context.Context, onChange func()) {
+	var (
+		lastIndex uint64
+		backoff   = catalogWatchMinBackoff
+	)
+
+	for {
+		opts := (&consulapi.QueryOptions{
+			WaitIndex: lastIndex,
+			WaitTime:  catalogWatchWaitTime,
+		}).WithContext(ctx)
+
+		_, meta, err := c.client.Catalog().Services(opts)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			if !sleepContext(ctx, backoff) {
+				return
+			}
+			backoff = min(backoff*2, catalogWatchMaxBackoff)
+			continue
+		}
+		backoff = catalogWatchMinBackoff
+
+		// 索引为 0 时按 1 处理，避免阻塞查询立即返回造成空转
+		index := max(meta.LastIndex, 1)
+		changed := lastIndex != 0 && index != lastIndex
+		if index < lastIndex {
+			// 索引回退（如 Consul 集群重建），下次从头开始监听
+			index = 0
+		}
+		if changed {
+			onChange()
+			if !sleepContext(ctx, catalogWatchMinInterval) {
+				return
+			}
+		}
+		lastIndex = index
+	}
+}
+
+// sleepContext 等待指定时长，ctx 结束时提前返回 false
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
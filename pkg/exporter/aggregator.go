@@ -228,3 +228,76 @@ func (agg *Aggregator) convertInspectionsToTimeline(inspections []models.Exporte
 	}
 	return timeline
 }
+
+// TenantStatus 单个租户的巡检汇总，用于跨租户报告
+type TenantStatus struct {
+	TenantId   string
+	TenantName string
+	Summary    models.ExporterStatusSummary
+	DownList   []models.ExporterStatus
+}
+
+// GetPlatformStatus 汇总所有启用巡检的租户的最新巡检结果
+// 返回全平台的统计摘要以及每个租户的摘要和异常列表
+func (agg *Aggregator) GetPlatformStatus() (models.ExporterStatusSummary, []TenantStatus, error) {
+	total := models.ExporterStatusSummary{
+		LastUpdateTime: time.Now(),
+	}
+
+	tenants, err := agg.ctx.DB.Tenant().GetAll()
+	if err != nil {
+		return total, nil, fmt.Errorf("查询租户列表失败: %w", err)
+	}
+
+	// 如果没有租户，使用默认租户
+	if len(tenants) == 0 {
+		tenants = append(tenants, models.Tenant{ID: "default", Name: "default"})
+	}
+
+	tenantStatuses := make([]TenantStatus, 0, len(tenants))
+	for _, tenant := range tenants {
+		config, err := agg.ctx.DB.ExporterMonitor().GetConfig(tenant.ID)
+		if err != nil {
+			logc.Errorf(agg.ctx.Ctx, "获取租户 %s 的巡检配置失败: %v", tenant.ID, err)
+			continue
+		}
+
+		// 未启用巡检的租户不参与汇总
+		if !config.GetEnabled() {
+			continue
+		}
+
+		datasourceIds, err := agg.resolveDatasourceIds(tenant.ID, "")
+		if err != nil {
+			logc.Errorf(agg.ctx.Ctx, "获取租户 %s 的数据源失败: %v", tenant.ID, err)
+			continue
+		}
+
+		summary, exporters, err := agg.aggregateInspectionResults(tenant.ID, datasourceIds, "down", "", "")
+		if err != nil {
+			logc.Errorf(agg.ctx.Ctx, "聚合租户 %s 的巡检结果失败: %v", tenant.ID, err)
+			continue
+		}
+		agg.calculateAvailabilityRate(&summary)
+
+		tenantName := tenant.Name
+		if tenantName == "" {
+			tenantName = tenant.ID
+		}
+
+		tenantStatuses = append(tenantStatuses, TenantStatus{
+			TenantId:   tenant.ID,
+			TenantName: tenantName,
+			Summary:    summary,
+			DownList:   exporters,
+		})
+		agg.mergeSummary(&total, &summary)
+	}
+
+	agg.calculateAvailabilityRate(&total)
+
+	logc.Infof(agg.ctx.Ctx, "全平台聚合完成: tenants=%d, total=%d, up=%d, down=%d, unknown=%d, availability=%.2f%%",
+		len(tenantStatuses), total.TotalCount, total.UpCount, total.DownCount, total.UnknownCount, total.AvailabilityRate)
+
+	return total, tenantStatuses, nil
+}
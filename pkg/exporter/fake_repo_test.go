@@ -0,0 +1,55 @@
+package exporter
+
+import (
+	"alertHub/internal/models"
+	"alertHub/internal/repo"
+	"errors"
+)
+
+// fakeEntryRepo 只实现巡检汇总用到的仓储，其余方法调用时 panic
+type fakeEntryRepo struct {
+	repo.InterEntryRepo
+	tenant          *fakeTenantRepo
+	exporterMonitor *fakeExporterMonitorRepo
+}
+
+func (f *fakeEntryRepo) Tenant() repo.InterTenantRepo { return f.tenant }
+
+func (f *fakeEntryRepo) ExporterMonitor() repo.InterExporterMonitorRepo { return f.exporterMonitor }
+
+type fakeTenantRepo struct {
+	repo.InterTenantRepo
+	data []models.Tenant
+}
+
+func (f *fakeTenantRepo) GetAll() ([]models.Tenant, error) { return f.data, nil }
+
+// fakeExporterMonitorRepo 内存中的巡检配置、最新巡检记录及明细
+type fakeExporterMonitorRepo struct {
+	repo.InterExporterMonitorRepo
+	configs     map[string]models.ExporterMonitorConfig
+	inspections map[string]*models.ExporterInspection // key: tenantId/datasourceId
+	details     map[string][]models.ExporterInspectionDetail
+}
+
+func (f *fakeExporterMonitorRepo) GetConfig(tenantId string) (models.ExporterMonitorConfig, error) {
+	config, ok := f.configs[tenantId]
+	if !ok {
+		return models.ExporterMonitorConfig{}, errors.New("config not found")
+	}
+	return config, nil
+}
+
+func (f *fakeExporterMonitorRepo) GetLatestInspection(tenantId, datasourceId string) (*models.ExporterInspection, error) {
+	return f.inspections[tenantId+"/"+datasourceId], nil
+}
+
+func (f *fakeExporterMonitorRepo) GetInspectionDetails(inspectionId string, status, job, keyword string) ([]models.ExporterInspectionDetail, error) {
+	var out []models.ExporterInspectionDetail
+	for _, d := range f.details[inspectionId] {
+		if status == "" || d.Status == status {
+			out = append(out, d)
+		}
+	}
+	return out, nil
+}
@@ -14,22 +14,44 @@ import (
 
 // 常量定义
 const (
-	reportTitle            = "📊 Exporter 健康巡检报告"
-	maxTrendRecords        = 10
-	maxFunctionLineCount   = 30
-	defaultAvailableRate   = 0.0
-	minAvailableRateGood   = 95.0
-	minAvailableRateNormal = 80.0
+	reportTitle          = "📊 Exporter 健康巡检报告"
+	maxTrendRecords      = 10
+	maxFunctionLineCount = 30
+	defaultAvailableRate = 0.0
 )
 
+// AvailabilityThresholds 可用率阈值，决定报告中可用率的颜色与状态图标
+type AvailabilityThresholds struct {
+	Good       float64 // 不低于该值视为良好
+	Warn       float64 // 不低于该值视为告警，低于则为严重
+	Configured bool    // 租户是否显式配置了阈值，未配置时不启用严重图标，保持原有展示
+}
+
+// DefaultAvailabilityThresholds 默认可用率阈值 95/80
+func DefaultAvailabilityThresholds() AvailabilityThresholds {
+	return AvailabilityThresholds{Good: models.DefaultGoodAvailability, Warn: models.DefaultWarnAvailability}
+}
+
+// LoadAvailabilityThresholds 读取租户配置的可用率阈值，未配置时使用默认值
+func LoadAvailabilityThresholds(c *ctx.Context, tenantId string) AvailabilityThresholds {
+	config, err := c.DB.ExporterMonitor().GetConfig(tenantId)
+	if err != nil {
+		return DefaultAvailabilityThresholds()
+	}
+
+	good, warn := config.GetAvailabilityThresholds()
+	return AvailabilityThresholds{Good: good, Warn: warn, Configured: config.HasAvailabilityThresholds()}
+}
+
 // Notifier 通知发送器 - 负责向通知组发送巡检报告
 type Notifier struct {
-	ctx *ctx.Context
+	ctx        *ctx.Context
+	thresholds AvailabilityThresholds
 }
 
 // NewNotifier 创建通知发送器实例
 func NewNotifier(c *ctx.Context) *Notifier {
-	return &Notifier{ctx: c}
+	return &Notifier{ctx: c, thresholds: DefaultAvailabilityThresholds()}
 }
 
 // SendToNoticeGroups 向通知组发送报告
@@ -38,6 +60,9 @@ func (n *Notifier) SendToNoticeGroups(tenantId string, noticeGroups []string, co
 		return fmt.Errorf("通知组列表为空")
 	}
 
+	// 按租户配置的阈值渲染可用率颜色与状态图标
+	n.thresholds = LoadAvailabilityThresholds(n.ctx, tenantId)
+
 	results := n.sendToAllGroups(tenantId, noticeGroups, content)
 	return n.buildSendResult(results, len(noticeGroups))
 }
@@ -69,27 +94,46 @@ func (n *Notifier) sendToSingleGroup(tenantId, groupId, content string) sendResu
 		return sendResult{groupId: groupId, success: false, err: err}
 	}
 
-	msgBytes, err := n.buildMessage(notice.NoticeType, content)
+	messages, err := n.buildMessages(notice.NoticeType, content)
 	if err != nil {
 		logc.Errorf(n.ctx.Ctx, "构建消息失败: notice=%s, err=%v", notice.Name, err)
 		return sendResult{groupId: groupId, success: false, err: err}
 	}
 
-	err = n.sendMessage(tenantId, &notice, msgBytes)
-	if err != nil {
-		logc.Errorf(n.ctx.Ctx, "发送消息失败: notice=%s, err=%v", notice.Name, err)
-		return sendResult{groupId: groupId, success: false, err: err}
+	// 超长报告可能被拆分为多条消息，按顺序发送
+	for _, msgBytes := range messages {
+		err = n.sendMessage(tenantId, &notice, msgBytes)
+		if err != nil {
+			logc.Errorf(n.ctx.Ctx, "发送消息失败: notice=%s, err=%v", notice.Name, err)
+			return sendResult{groupId: groupId, success: false, err: err}
+		}
 	}
 
 	logc.Infof(n.ctx.Ctx, "消息发送成功: notice=%s", notice.Name)
 	return sendResult{groupId: groupId, success: true, err: nil}
 }
 
-// buildMessage 根据通知类型构建消息
-func (n *Notifier) buildMessage(noticeType, content string) ([]byte, error) {
+// buildMessages 根据通知类型构建消息，支持分片的构建器可能返回多条
+func (n *Notifier) buildMessages(noticeType, content string) ([][]byte, error) {
 	builder := n.getMessageBuilder(noticeType)
-	msgContent := builder.Build(content)
-	return sonic.Marshal(msgContent)
+
+	var contents []map[string]interface{}
+	if chunked, ok := builder.(ChunkedMessageBuilder); ok {
+		contents = chunked.BuildChunks(content)
+	} else {
+		contents = []map[string]interface{}{builder.Build(content)}
+	}
+
+	messages := make([][]byte, 0, len(contents))
+	for _, msgContent := range contents {
+		msgBytes, err := sonic.Marshal(msgContent)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, msgBytes)
+	}
+
+	return messages, nil
 }
 
 // getMessageBuilder 获取消息构建器
@@ -97,6 +141,9 @@ func (n *Notifier) getMessageBuilder(noticeType string) MessageBuilder {
 	builders := map[string]MessageBuilder{
 		"DingDing": &DingDingBuilder{notifier: n},
 		"FeiShu":   &FeiShuBuilder{notifier: n},
+		"Slack":    &SlackBuilder{thresholds: n.thresholds},
+		"Teams":    &TeamsBuilder{thresholds: n.thresholds},
+		"Telegram": &TelegramBuilder{},
 	}
 
 	if builder, exists := builders[noticeType]; exists {
@@ -160,6 +207,12 @@ type MessageBuilder interface {
 	Build(content string) map[string]interface{}
 }
 
+// ChunkedMessageBuilder 支持将超长报告拆分为多条消息的构建器
+type ChunkedMessageBuilder interface {
+	MessageBuilder
+	BuildChunks(content string) []map[string]interface{}
+}
+
 // DingDingBuilder 钉钉消息构建器
 type DingDingBuilder struct {
 	notifier *Notifier
@@ -238,7 +291,7 @@ type FeiShuBuilder struct {
 
 // Build 构建飞书消息
 func (b *FeiShuBuilder) Build(content string) map[string]interface{} {
-	parser := NewContentParser(content)
+	parser := NewContentParser(content, b.notifier.thresholds)
 	elements, hasDown := parser.Parse()
 
 	cardTemplate := "blue"
@@ -282,17 +335,19 @@ func (b *DefaultBuilder) Build(content string) map[string]interface{} {
 
 // ContentParser 内容解析器
 type ContentParser struct {
-	lines   []string
-	index   int
-	hasDown bool
+	lines      []string
+	index      int
+	hasDown    bool
+	thresholds AvailabilityThresholds
 }
 
 // NewContentParser 创建内容解析器
-func NewContentParser(content string) *ContentParser {
+func NewContentParser(content string, thresholds AvailabilityThresholds) *ContentParser {
 	return &ContentParser{
-		lines:   strings.Split(content, "\n"),
-		index:   0,
-		hasDown: false,
+		lines:      strings.Split(content, "\n"),
+		index:      0,
+		hasDown:    false,
+		thresholds: thresholds,
 	}
 }
 
@@ -325,6 +380,42 @@ func (p *ContentParser) Parse() ([]map[string]interface{}, bool) {
 	return elements, p.hasDown
 }
 
+// ParseData 提取报告中的巡检时间、统计信息与异常列表
+// 供不使用飞书卡片元素的构建器复用，与 Parse 展示的数据保持一致
+func (p *ContentParser) ParseData() ReportData {
+	data := ReportData{}
+
+	for p.index < len(p.lines) {
+		line := strings.TrimSpace(p.lines[p.index])
+
+		switch {
+		case strings.HasPrefix(line, "**巡检时间**:"):
+			data.InspectionTime = strings.TrimSpace(strings.TrimPrefix(line, "**巡检时间**:"))
+			p.index++
+		case strings.Contains(line, "📈 总体统计"):
+			p.index++
+			data.Statistics = p.extractStatistics()
+		case strings.Contains(line, "⚠️ 异常 Exporter 列表"):
+			p.index++
+			data.DownList = p.extractDownList()
+			p.extractErrorDetails(data.DownList)
+		default:
+			p.index++
+		}
+	}
+
+	if len(data.DownList) > 0 || (data.Statistics != nil && data.Statistics.DownCount > 0) {
+		p.hasDown = true
+	}
+
+	return data
+}
+
+// HasDown 是否存在异常 Exporter
+func (p *ContentParser) HasDown() bool {
+	return p.hasDown
+}
+
 // shouldSkip 判断是否应跳过该行
 func (p *ContentParser) shouldSkip(line string) bool {
 	return line == "" ||
@@ -343,6 +434,7 @@ func (p *ContentParser) parseSection(line string) []map[string]interface{} {
 		{func(l string) bool { return strings.Contains(l, "✅ 所有 Exporter 运行正常") }, p.parseNormalSection},
 		{func(l string) bool { return strings.Contains(l, "📋 异常详情") }, p.parseDetailedSection},
 		{func(l string) bool { return strings.Contains(l, "📉 近 7 日趋势") }, p.parseTrendsSection},
+		{func(l string) bool { return strings.Contains(l, platformReportSection) }, p.parseTenantRankSection},
 	}
 
 	for _, sp := range sectionParsers {
@@ -367,7 +459,7 @@ func (p *ContentParser) parseStatisticsSection() []map[string]interface{} {
 		p.hasDown = true
 	}
 
-	return buildStatisticsCard(stats)
+	return buildStatisticsCard(stats, p.thresholds)
 }
 
 // extractStatistics 提取统计数据
@@ -708,6 +800,44 @@ func (p *ContentParser) extractTrends() []map[string]interface{} {
 	return elements
 }
 
+// parseTenantRankSection 解析跨租户报告的租户排行段落
+func (p *ContentParser) parseTenantRankSection() []map[string]interface{} {
+	p.index++
+
+	// 跳过表头
+	for p.index < len(p.lines) {
+		line := strings.TrimSpace(p.lines[p.index])
+		p.index++
+		if strings.HasPrefix(line, "|") && strings.Contains(line, "租户") {
+			break
+		}
+	}
+
+	elements := []map[string]interface{}{createTextElement("**" + platformReportSection + "**")}
+	for p.index < len(p.lines) {
+		line := strings.TrimSpace(p.lines[p.index])
+
+		// 遇到空行或新段落，停止解析
+		if line == "" || strings.HasPrefix(line, "###") {
+			break
+		}
+
+		if strings.HasPrefix(line, "|") && !strings.Contains(line, "---") {
+			if elem := parseTenantRankRow(line, p.thresholds); elem != nil {
+				elements = append(elements, elem)
+			}
+		}
+
+		p.index++
+	}
+
+	if len(elements) == 1 {
+		return nil
+	}
+
+	return append(elements, map[string]interface{}{"tag": "hr"})
+}
+
 // buildFooter 构建底部信息
 func (p *ContentParser) buildFooter() []map[string]interface{} {
 	return []map[string]interface{}{
@@ -736,6 +866,13 @@ type Statistics struct {
 	Status           string
 }
 
+// ReportData 从报告内容中提取的结构化数据
+type ReportData struct {
+	InspectionTime string
+	Statistics     *Statistics
+	DownList       []DownItem
+}
+
 // DownItem 异常项
 type DownItem struct {
 	Index      string
@@ -750,11 +887,11 @@ type DownItem struct {
 // ========== 卡片构建器 ==========
 
 // buildStatisticsCard 构建统计信息卡片
-func buildStatisticsCard(stats *Statistics) []map[string]interface{} {
+func buildStatisticsCard(stats *Statistics, thresholds AvailabilityThresholds) []map[string]interface{} {
 	elements := []map[string]interface{}{}
 
 	// 状态行 - 更突出
-	statusIcon := getStatusIcon(stats.Status)
+	statusIcon := getStatusIcon(stats, thresholds)
 	statusColor := "green"
 	if stats.DownCount > 0 {
 		statusColor = "red"
@@ -769,7 +906,7 @@ func buildStatisticsCard(stats *Statistics) []map[string]interface{} {
 	// 第一行：总数和可用率
 	row1 := []map[string]interface{}{
 		createColumn("📊 总数", fmt.Sprintf("**%d**", stats.TotalCount), ""),
-		createColumn("📈 可用率", fmt.Sprintf("**%.1f%%**", stats.AvailabilityRate), getRateColor(stats.AvailabilityRate)),
+		createColumn("📈 可用率", fmt.Sprintf("**%.1f%%**", stats.AvailabilityRate), getRateColor(stats.AvailabilityRate, thresholds)),
 	}
 	elements = append(elements, map[string]interface{}{
 		"tag":              "column_set",
@@ -796,9 +933,13 @@ func buildStatisticsCard(stats *Statistics) []map[string]interface{} {
 	return elements
 }
 
-// getStatusIcon 获取状态图标
-func getStatusIcon(status string) string {
+// getStatusIcon 获取状态图标，显式配置阈值后，存在异常且可用率低于告警阈值时使用严重图标
+func getStatusIcon(stats *Statistics, thresholds AvailabilityThresholds) string {
+	status := stats.Status
 	if strings.Contains(status, "异常") {
+		if thresholds.Configured && stats.TotalCount > 0 && stats.AvailabilityRate < thresholds.Warn {
+			return "🚨"
+		}
 		return "⚠️"
 	}
 	if strings.Contains(status, "未知") {
@@ -808,12 +949,12 @@ func getStatusIcon(status string) string {
 }
 
 // buildStatisticsColumns 构建统计列
-func buildStatisticsColumns(stats *Statistics) []map[string]interface{} {
+func buildStatisticsColumns(stats *Statistics, thresholds AvailabilityThresholds) []map[string]interface{} {
 	return []map[string]interface{}{
 		createColumn("📊 总数", fmt.Sprintf("**%d**", stats.TotalCount), ""),
 		createColumn("✅ 正常", fmt.Sprintf("**%d**", stats.UpCount), "green"),
 		createColumn("❌ 异常", fmt.Sprintf("**%d**", stats.DownCount), "red"),
-		createColumn("📈 可用率", fmt.Sprintf("**%.2f%%**", stats.AvailabilityRate), getRateColor(stats.AvailabilityRate)),
+		createColumn("📈 可用率", fmt.Sprintf("**%.2f%%**", stats.AvailabilityRate), getRateColor(stats.AvailabilityRate, thresholds)),
 	}
 }
 
@@ -835,11 +976,11 @@ func createColumn(title, value, color string) map[string]interface{} {
 }
 
 // getRateColor 获取可用率颜色
-func getRateColor(rate float64) string {
-	if rate >= minAvailableRateGood {
+func getRateColor(rate float64, thresholds AvailabilityThresholds) string {
+	if rate >= thresholds.Good {
 		return "blue"
 	}
-	if rate >= minAvailableRateNormal {
+	if rate >= thresholds.Warn {
 		return "orange"
 	}
 	return "red"
@@ -891,9 +1032,7 @@ func buildDownItemContent(item DownItem) string {
 	if item.Error != "" {
 		errorMsg := cleanValue(item.Error)
 		// 限制错误信息长度为 150 字符
-		if len(errorMsg) > 150 {
-			errorMsg = errorMsg[:147] + "..."
-		}
+		errorMsg = truncateError(errorMsg)
 		content += fmt.Sprintf("\n**错误详情**: %s", errorMsg)
 	}
 
@@ -921,6 +1060,31 @@ func cleanValue(value string) string {
 	return strings.TrimSpace(value)
 }
 
+// cleanDownItem 清理异常项中的 markdown 格式标记，并限制错误详情长度
+func cleanDownItem(item DownItem) DownItem {
+	item.Instance = cleanValue(item.Instance)
+	item.Job = cleanValue(item.Job)
+	item.Datasource = cleanValue(item.Datasource)
+	item.Time = cleanValue(item.Time)
+
+	if item.Error != "" {
+		item.Error = cleanValue(item.Error)
+		// 限制错误信息长度为 150 字符
+		item.Error = truncateError(item.Error)
+	}
+
+	return item
+}
+
+// truncateError 按字符截断错误信息，超过 150 字符时保留前 147 个字符并追加省略号
+func truncateError(msg string) string {
+	runes := []rune(msg)
+	if len(runes) <= 150 {
+		return msg
+	}
+	return string(runes[:147]) + "..."
+}
+
 // removeHTMLTags 移除 HTML 标签
 func removeHTMLTags(s string) string {
 	result := strings.Builder{}
@@ -1000,6 +1164,31 @@ func parseDetailedRow(line string) map[string]interface{} {
 	return createTextElement(content)
 }
 
+// parseTenantRankRow 解析租户排行行
+// 格式：| # | 租户 | 总数 | 正常 | 异常 | 可用率 |
+func parseTenantRankRow(line string, thresholds AvailabilityThresholds) map[string]interface{} {
+	parts := strings.Split(line, "|")
+	if len(parts) < 7 {
+		return nil
+	}
+
+	rateText := strings.TrimSpace(parts[6])
+	rate, _ := parsePercentage(rateText)
+
+	content := fmt.Sprintf(
+		"%s. **%s** | 总数: %s | 正常: <font color='green'>%s</font> | 异常: <font color='red'>%s</font> | 可用率: <font color='%s'>%s</font>",
+		strings.TrimSpace(parts[1]),
+		strings.TrimSpace(parts[2]),
+		strings.TrimSpace(parts[3]),
+		strings.TrimSpace(parts[4]),
+		strings.TrimSpace(parts[5]),
+		getRateColor(rate, thresholds),
+		rateText,
+	)
+
+	return createTextElement(content)
+}
+
 // parseTrendRow 解析趋势行
 func parseTrendRow(line string) map[string]interface{} {
 	parts := strings.Split(line, "|")
@@ -0,0 +1,137 @@
+package exporter
+
+import (
+	"fmt"
+	"time"
+)
+
+// maxSlackDownItems Slack 单条消息最多 50 个 block，异常列表超出部分折叠展示
+const maxSlackDownItems = 20
+
+// SlackBuilder Slack 消息构建器，输出 Block Kit 格式
+type SlackBuilder struct {
+	thresholds AvailabilityThresholds
+}
+
+// Build 构建 Slack 消息
+func (b *SlackBuilder) Build(content string) map[string]interface{} {
+	parser := NewContentParser(content, b.thresholds)
+	data := parser.ParseData()
+
+	blocks := []map[string]interface{}{
+		{
+			"type": "header",
+			"text": map[string]interface{}{
+				"type":  "plain_text",
+				"text":  reportTitle,
+				"emoji": true,
+			},
+		},
+	}
+
+	if data.InspectionTime != "" {
+		blocks = append(blocks, slackContext(fmt.Sprintf("*巡检时间*: %s", data.InspectionTime)))
+	}
+
+	if data.Statistics != nil {
+		blocks = append(blocks, buildSlackStatisticsBlocks(data.Statistics, b.thresholds)...)
+	}
+
+	blocks = append(blocks, map[string]interface{}{"type": "divider"})
+
+	if len(data.DownList) > 0 {
+		blocks = append(blocks, buildSlackDownListBlocks(data.DownList)...)
+	} else if !parser.HasDown() {
+		blocks = append(blocks, slackSection("✅ *所有 Exporter 运行正常*\n本次巡检未发现任何异常，所有 Exporter 均正常运行。"))
+	}
+
+	blocks = append(blocks,
+		map[string]interface{}{"type": "divider"},
+		slackContext(fmt.Sprintf("⏰ 报告时间: %s | 本报告由 AlertHub Exporter 健康巡检系统自动生成", time.Now().Format("2006-01-02 15:04:05"))),
+	)
+
+	return map[string]interface{}{
+		// text 用于通知预览及不支持 blocks 的客户端
+		"text":   reportTitle,
+		"blocks": blocks,
+	}
+}
+
+// buildSlackStatisticsBlocks 构建统计信息 blocks
+func buildSlackStatisticsBlocks(stats *Statistics, thresholds AvailabilityThresholds) []map[string]interface{} {
+	status := fmt.Sprintf("%s *状态*: %s", getStatusIcon(stats, thresholds), stats.Status)
+
+	return []map[string]interface{}{
+		slackSection(status),
+		{
+			"type": "section",
+			"fields": []map[string]interface{}{
+				slackField(fmt.Sprintf("*📊 总数*\n%d", stats.TotalCount)),
+				slackField(fmt.Sprintf("*📈 可用率*\n%.1f%%", stats.AvailabilityRate)),
+				slackField(fmt.Sprintf("*✅ 正常*\n%d", stats.UpCount)),
+				slackField(fmt.Sprintf("*❌ 异常*\n%d", stats.DownCount)),
+			},
+		},
+	}
+}
+
+// buildSlackDownListBlocks 构建异常列表 blocks，每个异常 Exporter 一个 section
+func buildSlackDownListBlocks(downList []DownItem) []map[string]interface{} {
+	blocks := []map[string]interface{}{
+		slackSection(fmt.Sprintf("*⚠️ 异常 Exporter 列表 (%d)*", len(downList))),
+	}
+
+	for idx, item := range downList {
+		if idx >= maxSlackDownItems {
+			blocks = append(blocks, slackContext(fmt.Sprintf("… 其余 %d 个异常 Exporter 请登录 AlertHub 查看", len(downList)-maxSlackDownItems)))
+			break
+		}
+
+		item = cleanDownItem(item)
+		fields := []map[string]interface{}{
+			slackField(fmt.Sprintf("*Job*\n%s", item.Job)),
+			slackField(fmt.Sprintf("*数据源*\n%s", item.Datasource)),
+			slackField(fmt.Sprintf("*采集时间*\n%s", item.Time)),
+		}
+		if item.Error != "" {
+			fields = append(fields, slackField(fmt.Sprintf("*错误详情*\n%s", item.Error)))
+		}
+
+		blocks = append(blocks, map[string]interface{}{
+			"type":   "section",
+			"text":   map[string]interface{}{"type": "mrkdwn", "text": fmt.Sprintf("*%s. %s*", item.Index, item.Instance)},
+			"fields": fields,
+		})
+	}
+
+	return blocks
+}
+
+// slackSection 创建 mrkdwn 文本 section
+func slackSection(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "section",
+		"text": map[string]interface{}{
+			"type": "mrkdwn",
+			"text": text,
+		},
+	}
+}
+
+// slackField 创建 section 的 mrkdwn 字段
+func slackField(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "mrkdwn",
+		"text": text,
+	}
+}
+
+// slackContext 创建 context block
+func slackContext(text string) map[string]interface{} {
+	return map[string]interface{}{
+		"type": "context",
+		"elements": []map[string]interface{}{
+			{"type": "mrkdwn", "text": text},
+		},
+	}
+}
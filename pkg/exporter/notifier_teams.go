@@ -0,0 +1,123 @@
+package exporter
+
+import (
+	"alertHub/internal/models"
+	"fmt"
+	"time"
+
+	"github.com/bytedance/sonic"
+)
+
+const (
+	// maxTeamsDownItems MessageCard 中展示的异常 Exporter 数量上限，避免超过 Teams 28KB 消息限制
+	maxTeamsDownItems = 20
+	teamsColorNormal  = "2EB886"
+	teamsColorDown    = "FF0000"
+)
+
+// TeamsBuilder Microsoft Teams 消息构建器，输出旧版 MessageCard 格式
+type TeamsBuilder struct {
+	thresholds AvailabilityThresholds
+}
+
+// Build 构建 Teams 消息
+func (b *TeamsBuilder) Build(content string) map[string]interface{} {
+	parser := NewContentParser(content, b.thresholds)
+	data := parser.ParseData()
+
+	card := models.NewTeamsMsgTemplate(reportTitle)
+	card.Title = reportTitle
+	card.ThemeColor = teamsColorNormal
+	if parser.HasDown() {
+		card.ThemeColor = teamsColorDown
+	}
+
+	if data.Statistics != nil {
+		card.Sections = append(card.Sections, buildTeamsStatisticsSection(data.Statistics, data.InspectionTime, b.thresholds))
+	}
+
+	if len(data.DownList) > 0 {
+		card.Sections = append(card.Sections, buildTeamsDownListSections(data.DownList)...)
+	} else if !parser.HasDown() {
+		card.Sections = append(card.Sections, models.TeamsSection{
+			Text:     "✅ 所有 Exporter 运行正常，本次巡检未发现任何异常。",
+			Markdown: true,
+		})
+	}
+
+	card.Sections = append(card.Sections, models.TeamsSection{
+		Text:     fmt.Sprintf("⏰ 报告时间: %s  \n本报告由 AlertHub Exporter 健康巡检系统自动生成", time.Now().Format("2006-01-02 15:04:05")),
+		Markdown: true,
+	})
+
+	return teamsCardToMap(card)
+}
+
+// buildTeamsStatisticsSection 构建统计信息 section
+func buildTeamsStatisticsSection(stats *Statistics, inspectionTime string, thresholds AvailabilityThresholds) models.TeamsSection {
+	facts := []models.TeamsFact{
+		{Name: "状态", Value: fmt.Sprintf("%s %s", getStatusIcon(stats, thresholds), stats.Status)},
+		{Name: "总数", Value: fmt.Sprintf("%d", stats.TotalCount)},
+		{Name: "正常", Value: fmt.Sprintf("%d", stats.UpCount)},
+		{Name: "异常", Value: fmt.Sprintf("%d", stats.DownCount)},
+		{Name: "可用率", Value: fmt.Sprintf("%.1f%%", stats.AvailabilityRate)},
+	}
+	if inspectionTime != "" {
+		facts = append(facts, models.TeamsFact{Name: "巡检时间", Value: inspectionTime})
+	}
+
+	return models.TeamsSection{
+		ActivityTitle: "📈 总体统计",
+		Facts:         facts,
+		Markdown:      true,
+	}
+}
+
+// buildTeamsDownListSections 构建异常列表 sections，每个异常 Exporter 一个 section
+func buildTeamsDownListSections(downList []DownItem) []models.TeamsSection {
+	sections := []models.TeamsSection{
+		{
+			ActivityTitle: fmt.Sprintf("⚠️ 异常 Exporter 列表 (%d)", len(downList)),
+			Markdown:      true,
+		},
+	}
+
+	for idx, item := range downList {
+		if idx >= maxTeamsDownItems {
+			sections = append(sections, models.TeamsSection{
+				Text:     fmt.Sprintf("… 其余 %d 个异常 Exporter 请登录 AlertHub 查看", len(downList)-maxTeamsDownItems),
+				Markdown: true,
+			})
+			break
+		}
+
+		item = cleanDownItem(item)
+		facts := []models.TeamsFact{
+			{Name: "Job", Value: item.Job},
+			{Name: "数据源", Value: item.Datasource},
+			{Name: "采集时间", Value: item.Time},
+		}
+		if item.Error != "" {
+			facts = append(facts, models.TeamsFact{Name: "错误详情", Value: item.Error})
+		}
+
+		sections = append(sections, models.TeamsSection{
+			ActivityTitle: fmt.Sprintf("%s. %s", item.Index, item.Instance),
+			Facts:         facts,
+			Markdown:      true,
+		})
+	}
+
+	return sections
+}
+
+// teamsCardToMap 将 MessageCard 转换为 MessageBuilder 要求的 map 结构
+func teamsCardToMap(card models.TeamsMsgTemplate) map[string]interface{} {
+	result := map[string]interface{}{}
+	data, err := sonic.Marshal(card)
+	if err != nil {
+		return result
+	}
+	_ = sonic.Unmarshal(data, &result)
+	return result
+}
@@ -0,0 +1,251 @@
+package exporter
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxTelegramMessageLength Telegram 单条消息上限为 4096 字符，预留转义与分片标记的余量
+	maxTelegramMessageLength = 3800
+	telegramCodeFence        = "```"
+)
+
+// telegramReservedChars MarkdownV2 中需要转义的保留字符
+const telegramReservedChars = "_*[]()~`>#+-=|{}.!\\"
+
+// TelegramBuilder Telegram 消息构建器，输出 MarkdownV2 格式
+// 超长报告按行拆分为多条 sendMessage，代码块不会被拆开
+type TelegramBuilder struct{}
+
+// Build 构建 Telegram 消息，仅返回第一段，完整内容请使用 BuildChunks
+func (b *TelegramBuilder) Build(content string) map[string]interface{} {
+	return b.BuildChunks(content)[0]
+}
+
+// BuildChunks 构建 Telegram 消息分片
+func (b *TelegramBuilder) BuildChunks(content string) []map[string]interface{} {
+	chunks := chunkTelegramBlocks(convertToTelegramBlocks(content), maxTelegramMessageLength)
+
+	messages := make([]map[string]interface{}, 0, len(chunks))
+	for _, chunk := range chunks {
+		messages = append(messages, map[string]interface{}{
+			"text":                     chunk,
+			"parse_mode":               "MarkdownV2",
+			"disable_web_page_preview": true,
+		})
+	}
+
+	return messages
+}
+
+// convertToTelegramBlocks 将 markdown 报告转换为 MarkdownV2 文本块
+// 普通行各自为一块，代码块整体为一块，分片时以块为最小单位
+func convertToTelegramBlocks(content string) []string {
+	lines := strings.Split(content, "\n")
+	blocks := []string{}
+
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+
+		// 代码块：收集到结束标记为止
+		if strings.HasPrefix(line, telegramCodeFence) {
+			codeLines := []string{}
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != telegramCodeFence; i++ {
+				codeLines = append(codeLines, escapeTelegramCode(lines[i]))
+			}
+			blocks = append(blocks, telegramCodeFence+"\n"+strings.Join(codeLines, "\n")+"\n"+telegramCodeFence)
+			continue
+		}
+
+		// 表格分隔行
+		if strings.HasPrefix(line, "|") && strings.Contains(line, "---") {
+			continue
+		}
+
+		blocks = append(blocks, convertTelegramLine(line))
+	}
+
+	// 第一块为空时去掉，避免消息以空行开头
+	for len(blocks) > 0 && blocks[0] == "" {
+		blocks = blocks[1:]
+	}
+
+	return blocks
+}
+
+// convertTelegramLine 转换单行 markdown
+func convertTelegramLine(line string) string {
+	// 标题转换为粗体
+	if strings.HasPrefix(line, "#") {
+		title := strings.TrimSpace(strings.TrimLeft(line, "#"))
+		return "*" + escapeTelegramText(cleanValue(title)) + "*"
+	}
+
+	// 表格行转换为以 | 分隔的文本
+	if strings.HasPrefix(line, "|") {
+		cells := []string{}
+		for _, cell := range strings.Split(strings.Trim(line, "|"), "|") {
+			cells = append(cells, convertTelegramInline(strings.TrimSpace(cell)))
+		}
+		return strings.Join(cells, " \\| ")
+	}
+
+	return convertTelegramInline(line)
+}
+
+// convertTelegramInline 转换行内格式：**粗体** 转为 *粗体*，`代码` 保留，其余字符转义
+func convertTelegramInline(text string) string {
+	text = removeHTMLTags(text)
+
+	var sb strings.Builder
+	for i := 0; i < len(text); {
+		switch {
+		case strings.HasPrefix(text[i:], "**"):
+			sb.WriteString("*")
+			i += 2
+		case text[i] == '`':
+			end := strings.IndexByte(text[i+1:], '`')
+			if end == -1 {
+				sb.WriteString("\\`")
+				i++
+				continue
+			}
+			sb.WriteString("`" + escapeTelegramCode(text[i+1:i+1+end]) + "`")
+			i += end + 2
+		default:
+			r, size := utf8.DecodeRuneInString(text[i:])
+			if strings.ContainsRune(telegramReservedChars, r) {
+				sb.WriteByte('\\')
+			}
+			sb.WriteRune(r)
+			i += size
+		}
+	}
+
+	return sb.String()
+}
+
+// escapeTelegramText 转义 MarkdownV2 普通文本中的保留字符
+func escapeTelegramText(text string) string {
+	var sb strings.Builder
+	for _, r := range text {
+		if strings.ContainsRune(telegramReservedChars, r) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// escapeTelegramCode 转义代码块中的 ` 和 \
+func escapeTelegramCode(text string) string {
+	text = strings.ReplaceAll(text, "\\", "\\\\")
+	return strings.ReplaceAll(text, "`", "\\`")
+}
+
+// chunkTelegramBlocks 将文本块合并为不超过 limit 的消息分片
+// 单个代码块超长时拆分为多个完整的代码块，保证每个分片中的代码块均已闭合
+func chunkTelegramBlocks(blocks []string, limit int) []string {
+	chunks := []string{}
+	current := ""
+
+	flush := func() {
+		if strings.TrimSpace(current) != "" {
+			chunks = append(chunks, current)
+		}
+		current = ""
+	}
+
+	for _, block := range blocks {
+		for _, part := range splitTelegramBlock(block, limit) {
+			if current != "" && utf8.RuneCountInString(current)+1+utf8.RuneCountInString(part) > limit {
+				flush()
+			}
+			if current == "" {
+				current = part
+			} else {
+				current += "\n" + part
+			}
+		}
+	}
+	flush()
+
+	if len(chunks) == 0 {
+		chunks = append(chunks, escapeTelegramText(reportTitle))
+	}
+
+	return chunks
+}
+
+// splitTelegramBlock 拆分超过 limit 的文本块，优先按行拆分，代码块拆分后分别闭合
+func splitTelegramBlock(block string, limit int) []string {
+	if utf8.RuneCountInString(block) <= limit {
+		return []string{block}
+	}
+
+	isCode := strings.HasPrefix(block, telegramCodeFence)
+	body := block
+	if isCode {
+		body = strings.TrimSuffix(strings.TrimPrefix(block, telegramCodeFence+"\n"), "\n"+telegramCodeFence)
+		limit -= 2*len(telegramCodeFence) + 2
+	}
+
+	parts := []string{}
+	current := ""
+	wrap := func() {
+		if current == "" {
+			return
+		}
+		if isCode {
+			current = telegramCodeFence + "\n" + current + "\n" + telegramCodeFence
+		}
+		parts = append(parts, current)
+		current = ""
+	}
+
+	for _, line := range strings.Split(body, "\n") {
+		for _, piece := range splitTelegramLine(line, limit) {
+			if current != "" && utf8.RuneCountInString(current)+1+utf8.RuneCountInString(piece) > limit {
+				wrap()
+			}
+			if current == "" {
+				current = piece
+			} else {
+				current += "\n" + piece
+			}
+		}
+	}
+	wrap()
+
+	return parts
+}
+
+// splitTelegramLine 按字符截断超长的单行，不在转义符与被转义字符之间断开
+func splitTelegramLine(line string, limit int) []string {
+	runes := []rune(line)
+	if len(runes) <= limit {
+		return []string{line}
+	}
+
+	pieces := []string{}
+	for len(runes) > limit {
+		cut := limit
+		// 末尾连续反斜杠为奇数个时，最后一个是转义符，需与后面的字符留在同一段；
+		// 偶数个时均为转义后的 \\，可以直接断开
+		backslashes := 0
+		for i := cut - 1; i >= 0 && runes[i] == '\\'; i-- {
+			backslashes++
+		}
+		if backslashes%2 == 1 && cut > 1 {
+			cut--
+		}
+		pieces = append(pieces, string(runes[:cut]))
+		runes = runes[cut:]
+	}
+	if len(runes) > 0 {
+		pieces = append(pieces, string(runes))
+	}
+
+	return pieces
+}
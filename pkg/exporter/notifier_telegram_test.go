@@ -0,0 +1,184 @@
+package exporter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEscapeTelegramText(t *testing.T) {
+	// 每个保留字符都应带转义符
+	var want strings.Builder
+	for _, r := range telegramReservedChars {
+		want.WriteString("\\" + string(r))
+	}
+	if got := escapeTelegramText(telegramReservedChars); got != want.String() {
+		t.Fatalf("got %s, want %s", got, want.String())
+	}
+
+	if got := escapeTelegramText("CPU 使用率 95.5% (node-1)!"); got != `CPU 使用率 95\.5% \(node\-1\)\!` {
+		t.Fatalf("got %s", got)
+	}
+}
+
+func TestConvertTelegramInline(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"粗体", "**宕机** 3 台", "*宕机* 3 台"},
+		{"行内代码内只转义反引号与反斜杠", "执行 `a_b.c\\d`", "执行 `a_b.c\\\\d`"},
+		{"未闭合反引号被转义", "a`b", "a\\`b"},
+		{"保留字符", "[x](y) #1 a=b|c {d} ~e", `\[x\]\(y\) \#1 a\=b\|c \{d\} \~e`},
+		{"HTML 标签被移除", "<b>up</b>-down", `up\-down`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := convertTelegramInline(tt.in); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEscapeTelegramCode(t *testing.T) {
+	if got := escapeTelegramCode("a`b\\c_d"); got != "a\\`b\\\\c_d" {
+		t.Fatalf("got %s", got)
+	}
+}
+
+func TestSplitTelegramLine(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		limit int
+		want  []string
+	}{
+		{"未超长", `ab\_c`, 10, []string{`ab\_c`}},
+		{"转义符不与被转义字符分开", `ab\_cd`, 3, []string{`ab`, `\_c`, `d`}},
+		// 末尾两个反斜杠是一个转义后的 \，可以直接断开
+		{"偶数个反斜杠可断开", `a\\b`, 3, []string{`a\\`, `b`}},
+		// 前两个反斜杠为转义后的 \，第三个转义 _
+		{"奇数个反斜杠回退一位", `a\\\_b`, 4, []string{`a\\`, `\_b`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitTelegramLine(tt.line, tt.limit)
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitTelegramLineKeepsEscapes(t *testing.T) {
+	line := escapeTelegramText(strings.Repeat(`a\_b*c.`, 200))
+
+	for _, limit := range []int{7, 8, 9, 10, 64, 101} {
+		pieces := splitTelegramLine(line, limit)
+		if strings.Join(pieces, "") != line {
+			t.Fatalf("limit %d: pieces do not reassemble the line", limit)
+		}
+		for _, piece := range pieces {
+			if n := utf8.RuneCountInString(piece); n > limit {
+				t.Fatalf("limit %d: piece has %d runes", limit, n)
+			}
+			if trailingBackslashes(piece)%2 == 1 {
+				t.Fatalf("limit %d: piece %q ends inside an escape sequence", limit, piece)
+			}
+		}
+	}
+}
+
+func TestBuildChunksOversizedCodeBlock(t *testing.T) {
+	// 代码块内容远超单条消息上限，且包含需要转义的字符
+	var codeLines []string
+	for i := 0; i < 400; i++ {
+		codeLines = append(codeLines, fmt.Sprintf("node-%03d `up` a_b*c \\ 1.0!", i))
+	}
+	// 单行超过上限的代码行
+	longLine := strings.Repeat("x\\", maxTelegramMessageLength)
+	codeLines = append(codeLines, longLine)
+
+	content := strings.Join([]string{
+		"# 巡检报告 (2025-03-01)",
+		"**异常** 节点: node-1.example.com",
+		"```",
+		strings.Join(codeLines, "\n"),
+		"```",
+		"结束 - done!",
+	}, "\n")
+
+	chunks := (&TelegramBuilder{}).BuildChunks(content)
+	if len(chunks) < 2 {
+		t.Fatalf("oversized report must be split, got %d chunk", len(chunks))
+	}
+
+	var gotCode []string
+	for i, msg := range chunks {
+		text := msg["text"].(string)
+		if msg["parse_mode"] != "MarkdownV2" {
+			t.Fatalf("chunk %d parse_mode %v", i, msg["parse_mode"])
+		}
+		if n := utf8.RuneCountInString(text); n > 4096 || n > maxTelegramMessageLength {
+			t.Fatalf("chunk %d has %d runes", i, n)
+		}
+
+		// 每个分片中的代码块都必须闭合
+		inCode := false
+		for _, line := range strings.Split(text, "\n") {
+			if line == telegramCodeFence {
+				inCode = !inCode
+				continue
+			}
+			if inCode {
+				gotCode = append(gotCode, line)
+			}
+		}
+		if inCode {
+			t.Fatalf("chunk %d leaves a code block open", i)
+		}
+	}
+
+	// 代码块拆分后内容完整且顺序不变，超长行按转义边界拆为多行
+	var wantCode []string
+	for _, line := range codeLines[:len(codeLines)-1] {
+		wantCode = append(wantCode, escapeTelegramCode(line))
+	}
+	if got := strings.Join(gotCode[:len(wantCode)], "\n"); got != strings.Join(wantCode, "\n") {
+		t.Fatal("code block lines were lost or reordered")
+	}
+	if got := strings.Join(gotCode[len(wantCode):], ""); got != escapeTelegramCode(longLine) {
+		t.Fatal("oversized code line was not reassembled intact")
+	}
+	for _, piece := range gotCode[len(wantCode):] {
+		if trailingBackslashes(piece)%2 == 1 {
+			t.Fatalf("oversized code line split inside an escape: %q", piece)
+		}
+	}
+
+	first := chunks[0]["text"].(string)
+	if !strings.HasPrefix(first, `*巡检报告 \(2025\-03\-01\)*`) {
+		t.Fatalf("title not escaped: %q", first[:60])
+	}
+	if !strings.Contains(first, `*异常* 节点: node\-1\.example\.com`) {
+		t.Fatal("inline text not escaped")
+	}
+	last := chunks[len(chunks)-1]["text"].(string)
+	if !strings.HasSuffix(last, `结束 \- done\!`) {
+		t.Fatalf("trailing text missing or unescaped: %q", last)
+	}
+}
+
+// trailingBackslashes 统计字符串末尾连续反斜杠的个数
+func trailingBackslashes(s string) int {
+	n := 0
+	for i := len(s) - 1; i >= 0 && s[i] == '\\'; i-- {
+		n++
+	}
+	return n
+}
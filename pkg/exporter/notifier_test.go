@@ -0,0 +1,51 @@
+package exporter
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestGetStatusIcon(t *testing.T) {
+	configured := AvailabilityThresholds{Good: 95, Warn: 80, Configured: true}
+
+	tests := []struct {
+		name       string
+		stats      Statistics
+		thresholds AvailabilityThresholds
+		want       string
+	}{
+		{"未配置阈值时低可用率仍为告警图标", Statistics{Status: "存在异常", TotalCount: 10, AvailabilityRate: 50}, DefaultAvailabilityThresholds(), "⚠️"},
+		{"配置阈值后低于告警阈值为严重图标", Statistics{Status: "存在异常", TotalCount: 10, AvailabilityRate: 50}, configured, "🚨"},
+		{"配置阈值后高于告警阈值为告警图标", Statistics{Status: "存在异常", TotalCount: 10, AvailabilityRate: 90}, configured, "⚠️"},
+		{"状态未知", Statistics{Status: "状态未知"}, configured, "❓"},
+		{"全部正常", Statistics{Status: "全部正常", TotalCount: 10, AvailabilityRate: 100}, configured, "✅"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getStatusIcon(&tt.stats, tt.thresholds); got != tt.want {
+				t.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanDownItemTruncatesByRune(t *testing.T) {
+	item := cleanDownItem(DownItem{Error: strings.Repeat("连接超时", 50)})
+
+	if !utf8.ValidString(item.Error) {
+		t.Fatalf("truncated error is not valid UTF-8: %q", item.Error)
+	}
+	if n := utf8.RuneCountInString(item.Error); n != 150 {
+		t.Fatalf("got %d runes, want 150", n)
+	}
+	if !strings.HasSuffix(item.Error, "...") {
+		t.Fatalf("got %q, want ellipsis suffix", item.Error)
+	}
+
+	short := cleanDownItem(DownItem{Error: "连接超时"})
+	if short.Error != "连接超时" {
+		t.Fatalf("got %q, want unchanged", short.Error)
+	}
+}
@@ -3,15 +3,33 @@ package exporter
 import (
 	"alertHub/internal/models"
 	"fmt"
+	"sort"
 	"time"
 )
 
+// 跨租户报告相关常量
+const (
+	defaultTopTenants     = 10
+	maxPlatformDownItems  = 20
+	platformReportSection = "🏢 租户排行"
+)
+
 // Reporter 报告生成器 - 负责生成 Exporter 健康巡检报告
-type Reporter struct{}
+type Reporter struct {
+	thresholds AvailabilityThresholds
+}
 
 // NewReporter 创建报告生成器实例
-func NewReporter() *Reporter {
-	return &Reporter{}
+func NewReporter(thresholds AvailabilityThresholds) *Reporter {
+	return &Reporter{thresholds: thresholds}
+}
+
+// downStatusIcon 存在异常时的状态图标，显式配置阈值后，可用率低于告警阈值时使用严重图标
+func (r *Reporter) downStatusIcon(summary models.ExporterStatusSummary) string {
+	if r.thresholds.Configured && summary.TotalCount > 0 && summary.AvailabilityRate < r.thresholds.Warn {
+		return "🚨"
+	}
+	return "⚠️"
 }
 
 // GenerateReportContent 生成报告内容 (支持 Markdown 格式)
@@ -34,7 +52,7 @@ func (r *Reporter) GenerateReportContent(
 	// 根据状态使用不同的表情符号和格式
 	statusIcon := "✅"
 	if summary.DownCount > 0 {
-		statusIcon = "⚠️"
+		statusIcon = r.downStatusIcon(summary)
 	}
 	if summary.UnknownCount > 0 && summary.DownCount == 0 {
 		statusIcon = "❓"
@@ -184,9 +202,9 @@ func (r *Reporter) GenerateReportContent(
 
 					// 根据可用率设置颜色
 					rateColor := "green"
-					if availabilityRate < 80 {
+					if availabilityRate < r.thresholds.Warn {
 						rateColor = "red"
-					} else if availabilityRate < 95 {
+					} else if availabilityRate < r.thresholds.Good {
 						rateColor = "orange"
 					}
 
@@ -210,3 +228,117 @@ func (r *Reporter) GenerateReportContent(
 
 	return content
 }
+
+// GeneratePlatformReportContent 生成跨租户汇总报告内容 (Markdown 格式)
+// 段落结构与单租户报告保持一致，复用各通知渠道的消息构建器
+// topTenants: 租户排行展示数量，<=0 时使用默认值
+func (r *Reporter) GeneratePlatformReportContent(
+	total models.ExporterStatusSummary,
+	tenants []TenantStatus,
+	topTenants int,
+) string {
+	if topTenants <= 0 {
+		topTenants = defaultTopTenants
+	}
+
+	now := time.Now().Format("2006-01-02 15:04:05")
+
+	content := "## 📊 Exporter 健康巡检报告\n\n"
+	content += fmt.Sprintf("**巡检时间**: %s\n\n", now)
+	content += fmt.Sprintf("**巡检范围**: 全平台 (%d 个租户)\n\n", len(tenants))
+
+	// 统计摘要
+	content += "### 📈 总体统计\n\n"
+	if total.DownCount == 0 && total.UnknownCount == 0 {
+		content += "✅ **状态**: 全部正常\n\n"
+	} else if total.DownCount > 0 {
+		content += fmt.Sprintf("%s **状态**: 发现 %d 个异常\n\n", r.downStatusIcon(total), total.DownCount)
+	} else {
+		content += fmt.Sprintf("❓ **状态**: 发现 %d 个未知状态\n\n", total.UnknownCount)
+	}
+	content += "| 指标 | 数值 |\n"
+	content += "|------|------|\n"
+	content += fmt.Sprintf("| 📊 总数 | **%d** |\n", total.TotalCount)
+	content += fmt.Sprintf("| ✅ 正常 | <font color='green'>**%d**</font> |\n", total.UpCount)
+	content += fmt.Sprintf("| ❌ 异常 | <font color='red'>**%d**</font> |\n", total.DownCount)
+	if total.UnknownCount > 0 {
+		content += fmt.Sprintf("| ❓ 未知 | <font color='orange'>**%d**</font> |\n", total.UnknownCount)
+	}
+	content += fmt.Sprintf("| 📈 可用率 | <font color='blue'>**%.2f%%**</font> |\n\n", total.AvailabilityRate)
+
+	// 租户排行：异常数降序，异常数相同时可用率升序
+	sorted := make([]TenantStatus, len(tenants))
+	copy(sorted, tenants)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Summary.DownCount != sorted[j].Summary.DownCount {
+			return sorted[i].Summary.DownCount > sorted[j].Summary.DownCount
+		}
+		return sorted[i].Summary.AvailabilityRate < sorted[j].Summary.AvailabilityRate
+	})
+	ranked := sorted
+	if len(ranked) > topTenants {
+		ranked = ranked[:topTenants]
+	}
+
+	if len(ranked) > 0 {
+		content += fmt.Sprintf("### %s\n\n", platformReportSection)
+		content += "| # | 租户 | 总数 | 正常 | 异常 | 可用率 |\n"
+		content += "|---|------|------|------|------|--------|\n"
+		for i, t := range ranked {
+			content += fmt.Sprintf("| %d | %s | %d | %d | %d | %.2f%% |\n",
+				i+1, t.TenantName, t.Summary.TotalCount, t.Summary.UpCount, t.Summary.DownCount, t.Summary.AvailabilityRate)
+		}
+		content += "\n"
+	}
+
+	// 异常列表：按租户排行顺序展示，数据源前缀租户名便于定位
+	downList := make([]models.ExporterStatus, 0)
+	downTotal := 0
+	for _, t := range sorted {
+		for _, exp := range t.DownList {
+			downTotal++
+			if len(downList) >= maxPlatformDownItems {
+				continue
+			}
+			exp.DatasourceName = fmt.Sprintf("%s/%s", t.TenantName, exp.DatasourceName)
+			downList = append(downList, exp)
+		}
+	}
+
+	if len(downList) > 0 {
+		content += fmt.Sprintf("### ⚠️ 异常 Exporter 列表 (%d)\n\n", downTotal)
+		content += "| # | 实例名称 | Job | 数据源 | 采集地址 | 最后采集时间 |\n"
+		content += "|---|---------|-----|--------|----------|-------------|\n"
+		for i, exp := range downList {
+			instanceName := exp.Instance
+			if len(instanceName) > 20 {
+				instanceName = instanceName[:17] + "..."
+			}
+			scrapeUrl := exp.ScrapeUrl
+			if len(scrapeUrl) > 30 {
+				scrapeUrl = scrapeUrl[:27] + "..."
+			}
+
+			content += fmt.Sprintf("| %d | **%s** | `%s` | %s | `%s` | %s |\n",
+				i+1,
+				instanceName,
+				exp.Job,
+				exp.DatasourceName,
+				scrapeUrl,
+				exp.LastScrapeTime.Format("01-02 15:04"),
+			)
+		}
+		content += "\n"
+		if downTotal > len(downList) {
+			content += fmt.Sprintf("*仅展示前 %d 个异常，共 %d 个*\n\n", len(downList), downTotal)
+		}
+	} else {
+		content += "### ✅ 所有 Exporter 运行正常\n\n"
+		content += "🎉 本次巡检未发现任何异常，所有 Exporter 均正常运行。\n\n"
+	}
+
+	content += "---\n\n"
+	content += "*本报告由 AlertHub Exporter 健康巡检系统自动生成*\n"
+
+	return content
+}
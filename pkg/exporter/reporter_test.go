@@ -0,0 +1,159 @@
+package exporter
+
+import (
+	"alertHub/internal/ctx"
+	"alertHub/internal/models"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newPlatformSnapshotAggregator 构造固定的全平台巡检快照
+// t-a: 两个数据源共 15 个 exporter，2 个异常；t-b: 4 个 exporter，3 个异常且未设置租户名
+// t-c 未启用巡检，t-d 获取配置失败，二者都不参与汇总
+func newPlatformSnapshotAggregator() *Aggregator {
+	enabled, disabled := true, false
+	scrapeTime := time.Date(2026, 1, 2, 3, 4, 0, 0, time.UTC)
+
+	downDetails := func(inspectionId, datasourceName string, n int) []models.ExporterInspectionDetail {
+		details := make([]models.ExporterInspectionDetail, 0, n+1)
+		for i := 0; i < n; i++ {
+			details = append(details, models.ExporterInspectionDetail{
+				InspectionId:   inspectionId,
+				DatasourceName: datasourceName,
+				Job:            "node",
+				Instance:       fmt.Sprintf("%s-%d:9100", datasourceName, i),
+				Status:         "down",
+				LastScrapeTime: scrapeTime,
+			})
+		}
+		return append(details, models.ExporterInspectionDetail{InspectionId: inspectionId, Status: "up"})
+	}
+
+	monitor := &fakeExporterMonitorRepo{
+		configs: map[string]models.ExporterMonitorConfig{
+			"t-a": {Enabled: &enabled, DatasourceIds: []string{"ds-a1", "ds-a2"}},
+			"t-b": {Enabled: &enabled, DatasourceIds: []string{"ds-b1"}},
+			"t-c": {Enabled: &disabled, DatasourceIds: []string{"ds-c1"}},
+		},
+		inspections: map[string]*models.ExporterInspection{
+			"t-a/ds-a1": {InspectionId: "i-a1", TotalCount: 10, UpCount: 8, DownCount: 2},
+			"t-a/ds-a2": {InspectionId: "i-a2", TotalCount: 5, UpCount: 5},
+			"t-b/ds-b1": {InspectionId: "i-b1", TotalCount: 4, UpCount: 1, DownCount: 3},
+			"t-c/ds-c1": {InspectionId: "i-c1", TotalCount: 7, DownCount: 7},
+		},
+		details: map[string][]models.ExporterInspectionDetail{
+			"i-a1": downDetails("i-a1", "prom-a1", 2),
+			"i-b1": downDetails("i-b1", "prom-b1", 3),
+			"i-c1": downDetails("i-c1", "prom-c1", 7),
+		},
+	}
+
+	db := &fakeEntryRepo{
+		tenant: &fakeTenantRepo{data: []models.Tenant{
+			{ID: "t-a", Name: "团队A"},
+			{ID: "t-b"},
+			{ID: "t-c", Name: "团队C"},
+			{ID: "t-d", Name: "团队D"},
+		}},
+		exporterMonitor: monitor,
+	}
+
+	return NewAggregator(&ctx.Context{Ctx: context.Background(), DB: db})
+}
+
+func TestGetPlatformStatus(t *testing.T) {
+	total, tenants, err := newPlatformSnapshotAggregator().GetPlatformStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if total.TotalCount != 19 || total.UpCount != 14 || total.DownCount != 5 || total.UnknownCount != 0 {
+		t.Fatalf("got total %+v, want 19/14/5/0", total)
+	}
+	if got := fmt.Sprintf("%.2f", total.AvailabilityRate); got != "73.68" {
+		t.Fatalf("got availability %s, want 73.68", got)
+	}
+
+	if len(tenants) != 2 {
+		t.Fatalf("got %d tenants, want 2 (disabled and failing tenants skipped)", len(tenants))
+	}
+
+	a, b := tenants[0], tenants[1]
+	if a.TenantId != "t-a" || a.TenantName != "团队A" || a.Summary.TotalCount != 15 || a.Summary.DownCount != 2 || len(a.DownList) != 2 {
+		t.Fatalf("got tenant a %+v", a)
+	}
+	if b.TenantId != "t-b" || b.TenantName != "t-b" || b.Summary.DownCount != 3 || len(b.DownList) != 3 {
+		t.Fatalf("got tenant b %+v, want name to fall back to the tenant ID", b)
+	}
+	if b.Summary.AvailabilityRate != 25 {
+		t.Fatalf("got tenant b availability %.2f, want 25", b.Summary.AvailabilityRate)
+	}
+	for _, exp := range append(a.DownList, b.DownList...) {
+		if exp.Status != "down" {
+			t.Fatalf("down list contains %s exporter %s", exp.Status, exp.Instance)
+		}
+	}
+}
+
+func TestGeneratePlatformReportContent(t *testing.T) {
+	total, tenants, err := newPlatformSnapshotAggregator().GetPlatformStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reporter := NewReporter(AvailabilityThresholds{Good: 95, Warn: 80, Configured: true})
+	content := reporter.GeneratePlatformReportContent(total, tenants, 1)
+
+	for _, want := range []string{
+		"**巡检范围**: 全平台 (2 个租户)",
+		"🚨 **状态**: 发现 5 个异常",
+		"| 📊 总数 | **19** |",
+		"| ✅ 正常 | <font color='green'>**14**</font> |",
+		"| ❌ 异常 | <font color='red'>**5**</font> |",
+		"| 📈 可用率 | <font color='blue'>**73.68%**</font> |",
+		"### " + platformReportSection,
+		"| 1 | t-b | 4 | 1 | 3 | 25.00% |",
+		"### ⚠️ 异常 Exporter 列表 (5)",
+		"| t-b/prom-b1 |",
+		"| 团队A/prom-a1 |",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("report missing %q\n%s", want, content)
+		}
+	}
+
+	// topTenants=1 时只展示异常最多的租户
+	if strings.Contains(content, "| 2 | 团队A |") {
+		t.Errorf("ranking must be limited to the top tenant\n%s", content)
+	}
+	// 异常列表按租户排行顺序展示
+	if strings.Index(content, "t-b/prom-b1") > strings.Index(content, "团队A/prom-a1") {
+		t.Errorf("down list must follow tenant ranking\n%s", content)
+	}
+	if strings.Contains(content, "未知") || strings.Contains(content, "团队C") || strings.Contains(content, "团队D") {
+		t.Errorf("report must not include unknown rows or skipped tenants\n%s", content)
+	}
+}
+
+func TestGeneratePlatformReportContentAllHealthy(t *testing.T) {
+	total := models.ExporterStatusSummary{TotalCount: 3, UpCount: 3, AvailabilityRate: 100}
+	tenants := []TenantStatus{{TenantId: "t-a", TenantName: "团队A", Summary: total}}
+
+	content := NewReporter(DefaultAvailabilityThresholds()).GeneratePlatformReportContent(total, tenants, 0)
+
+	for _, want := range []string{
+		"✅ **状态**: 全部正常",
+		"| 1 | 团队A | 3 | 3 | 0 | 100.00% |",
+		"### ✅ 所有 Exporter 运行正常",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("report missing %q\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "异常 Exporter 列表") {
+		t.Errorf("healthy report must not list down exporters\n%s", content)
+	}
+}
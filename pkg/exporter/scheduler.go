@@ -6,7 +6,9 @@ import (
 	"strings"
 	"sync"
 	"time"
+	"alertHub/config"
 	ctx2 "alertHub/internal/ctx"
+	"alertHub/internal/global"
 	"alertHub/internal/models"
 
 	"github.com/robfig/cron/v3"
@@ -25,6 +27,7 @@ var (
 // 1. 定时巡检任务 (根据 InspectionTimes 配置)
 // 2. 定时报告推送任务 (根据 CronExpression 配置)
 // 3. 历史数据清理任务 (每天凌晨执行)
+// 另外可选注册跨租户汇总报告任务 (根据 ExporterPlatformReport 配置)
 type Scheduler struct {
 	ctx            *ctx2.Context
 	cron           *cron.Cron
@@ -32,6 +35,7 @@ type Scheduler struct {
 	inspectionJobs map[string]cron.EntryID   // 巡检任务ID映射 (key: tenantId)
 	reportJobs     map[string][]cron.EntryID // 报告推送任务ID映射 (key: tenantId, value: 多个任务ID)
 	cleanupJobID   cron.EntryID              // 清理任务ID
+	platformJobID  cron.EntryID              // 跨租户汇总报告任务ID
 	cancelFunc     context.CancelFunc        // 用于优雅停止
 }
 
@@ -74,7 +78,12 @@ func (s *Scheduler) Start() error {
 		return err
 	}
 
-	// 4. 启动 cron 调度器
+	// 4. 注册跨租户汇总报告任务 (可选，失败不影响租户级任务)
+	if err := s.registerPlatformReportJob(global.Config.ExporterPlatformReport); err != nil {
+		logc.Errorf(s.ctx.Ctx, "[ExporterScheduler] 注册跨租户汇总报告任务失败: %v", err)
+	}
+
+	// 5. 启动 cron 调度器
 	s.cron.Start()
 
 	logc.Info(s.ctx.Ctx, "[ExporterScheduler] Exporter 巡检调度器启动成功")
@@ -345,7 +354,7 @@ func (s *Scheduler) registerReportJobs(tenantId string, schedule models.Exporter
 			historyData, _ := aggregator.GetHistory(capturedTenantId, "", startTime, endTime)
 
 			// 3. 生成报告内容
-			reporter := NewReporter()
+			reporter := NewReporter(LoadAvailabilityThresholds(s.ctx, capturedTenantId))
 			content := reporter.GenerateReportContent(summary, exporters, historyData, capturedReportFormat)
 
 			// 4. 发送到通知组
@@ -372,6 +381,54 @@ func (s *Scheduler) registerReportJobs(tenantId string, schedule models.Exporter
 	return nil
 }
 
+// registerPlatformReportJob 注册跨租户汇总报告推送任务
+// 未启用或未配置通知组时跳过
+func (s *Scheduler) registerPlatformReportJob(cfg config.ExporterPlatformReport) error {
+	if !cfg.Enabled {
+		return nil
+	}
+
+	if cfg.TenantId == "" || len(cfg.NoticeGroups) == 0 {
+		return fmt.Errorf("跨租户汇总报告需要配置 tenantId 和 noticeGroups")
+	}
+
+	if cfg.CronExpression == "" {
+		return fmt.Errorf("跨租户汇总报告未配置 cronExpression")
+	}
+
+	// 5 段式转换为 6 段式 (秒 分 时 日 月 周)
+	fullCronExpr := "0 " + cfg.CronExpression
+
+	entryID, err := s.cron.AddFunc(fullCronExpr, func() {
+		logc.Info(s.ctx.Ctx, "[ExporterScheduler] 触发跨租户汇总报告推送任务...")
+		if err := SendPlatformReport(s.ctx, cfg); err != nil {
+			logc.Errorf(s.ctx.Ctx, "[ExporterScheduler] 推送跨租户汇总报告失败: %v", err)
+		} else {
+			logc.Info(s.ctx.Ctx, "[ExporterScheduler] 跨租户汇总报告推送完成")
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("注册跨租户汇总报告任务失败 (Cron: %s): %w", cfg.CronExpression, err)
+	}
+
+	s.platformJobID = entryID
+	logc.Infof(s.ctx.Ctx, "[ExporterScheduler] 已注册跨租户汇总报告任务, Cron: %s, EntryID: %d", fullCronExpr, entryID)
+	return nil
+}
+
+// SendPlatformReport 汇总所有租户的巡检结果并推送到运维通知组
+func SendPlatformReport(c *ctx2.Context, cfg config.ExporterPlatformReport) error {
+	aggregator := NewAggregator(c)
+	total, tenants, err := aggregator.GetPlatformStatus()
+	if err != nil {
+		return fmt.Errorf("获取全平台巡检状态失败: %w", err)
+	}
+
+	content := NewReporter(LoadAvailabilityThresholds(c, cfg.TenantId)).GeneratePlatformReportContent(total, tenants, cfg.TopTenants)
+
+	return NewNotifier(c).SendToNoticeGroups(cfg.TenantId, cfg.NoticeGroups, content)
+}
+
 // cleanupHistoryData 清理过期的历史数据
 func (s *Scheduler) cleanupHistoryData() error {
 	// 从数据库查询所有租户
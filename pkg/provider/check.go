@@ -5,10 +5,20 @@ import (
 	consulclient "alertHub/pkg/consul"
 	"context"
 	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/zeromicro/go-zero/core/logc"
 )
 
+// defaultHealthCheckConcurrency 批量健康检查的默认并发数
+const defaultHealthCheckConcurrency = 8
+
+// urlUserInfoRe 匹配 URL 中的 user:pass@ 部分
+var urlUserInfoRe = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/@\s]+@`)
+
 // HealthChecker 统一健康检查接口
 type HealthChecker interface {
 	Check() (bool, error)
@@ -130,13 +140,76 @@ func CheckDatasourceHealth(datasource models.AlertDataSource) (bool, error) {
 	return true, nil
 }
 
-// 统一日志记录方法
+// 统一日志记录方法，错误信息脱敏后输出
 func logDatasourceError(ds models.AlertDataSource, err error) {
-	logc.Errorf(context.Background(), "Datasource error",
+	logc.Errorf(context.Background(), "Datasource error: %v",
 		map[string]interface{}{
 			"id":   ds.ID,
 			"name": ds.Name,
 			"type": ds.Type,
-			"err":  err.Error(),
+			"err":  sanitizeDatasourceError(ds, err.Error()),
 		})
 }
+
+// CheckDatasourcesHealth 并发检查多个数据源的健康状态
+// concurrency 限制同时进行的检查数，<=0 时使用默认值；结果顺序与入参一致
+func CheckDatasourcesHealth(datasources []models.AlertDataSource, concurrency int) []models.DatasourceHealthResult {
+	if concurrency <= 0 {
+		concurrency = defaultHealthCheckConcurrency
+	}
+
+	results := make([]models.DatasourceHealthResult, len(datasources))
+	semaphore := make(chan struct{}, concurrency)
+	wg := sync.WaitGroup{}
+
+	wg.Add(len(datasources))
+	for i, ds := range datasources {
+		go func(i int, ds models.AlertDataSource) {
+			semaphore <- struct{}{}
+			defer func() {
+				wg.Done()
+				<-semaphore
+			}()
+
+			start := time.Now()
+			ok, err := CheckDatasourceHealth(ds)
+			result := models.DatasourceHealthResult{
+				DatasourceId: ds.ID,
+				Name:         ds.Name,
+				Type:         ds.Type,
+				Reachable:    ok,
+				LatencyMs:    time.Since(start).Milliseconds(),
+				CheckedAt:    time.Now().Unix(),
+			}
+			if !ok {
+				if err == nil {
+					err = fmt.Errorf("health check failed")
+				}
+				result.Error = sanitizeDatasourceError(ds, err.Error())
+			}
+			results[i] = result
+		}(i, ds)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// sanitizeDatasourceError 移除错误信息中的认证信息，避免通过接口泄露密钥
+func sanitizeDatasourceError(ds models.AlertDataSource, msg string) string {
+	msg = urlUserInfoRe.ReplaceAllString(msg, "${1}***@")
+
+	secrets := []string{
+		ds.Auth.Pass,
+		ds.ConsulConfig.Token,
+		ds.DsAliCloudConfig.AliCloudSk,
+		ds.AWSCloudWatch.SecretKey,
+	}
+	for _, secret := range secrets {
+		if secret != "" {
+			msg = strings.ReplaceAll(msg, secret, "***")
+		}
+	}
+
+	return msg
+}
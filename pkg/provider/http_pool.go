@@ -0,0 +1,58 @@
+package provider
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// datasourceMaxIdleConnsPerHost 每个数据源保留的空闲连接数，默认值 2 在并发查询时会频繁新建连接
+	datasourceMaxIdleConnsPerHost = 32
+	// datasourceIdleConnTimeout 空闲连接的保留时间
+	datasourceIdleConnTimeout = 90 * time.Second
+)
+
+// datasourceHTTPClients 按数据源 ID 复用的 HTTP 客户端
+var datasourceHTTPClients sync.Map
+
+// GetDatasourceHTTPClient 获取数据源对应的 HTTP 客户端，同一数据源的请求复用连接池
+func GetDatasourceHTTPClient(datasourceId string) *http.Client {
+	if cli, ok := datasourceHTTPClients.Load(datasourceId); ok {
+		return cli.(*http.Client)
+	}
+
+	cli, _ := datasourceHTTPClients.LoadOrStore(datasourceId, newDatasourceHTTPClient())
+	return cli.(*http.Client)
+}
+
+// RemoveDatasourceHTTPClient 移除数据源对应的 HTTP 客户端并关闭空闲连接，数据源更新或删除时调用
+func RemoveDatasourceHTTPClient(datasourceId string) {
+	if cli, ok := datasourceHTTPClients.LoadAndDelete(datasourceId); ok {
+		cli.(*http.Client).CloseIdleConnections()
+	}
+}
+
+func newDatasourceHTTPClient() *http.Client {
+	transport := &http.Transport{
+		// 统一跳过证书检测，与 tools.Get 保持一致
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: true,
+		},
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   5 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: datasourceMaxIdleConnsPerHost,
+		IdleConnTimeout:     datasourceIdleConnTimeout,
+	}
+
+	// 超时时间按数据源配置在每个请求的 context 中设置
+	return &http.Client{
+		Transport: transport,
+	}
+}
@@ -0,0 +1,116 @@
+package provider
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newCountingServer 启动测试服务，统计新建连接数与已关闭连接数
+func newCountingServer(t *testing.T) (*httptest.Server, *atomic.Int64, *atomic.Int64) {
+	t.Helper()
+	var opened, closed atomic.Int64
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"status":"success"}`))
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateNew:
+			opened.Add(1)
+		case http.StateClosed:
+			closed.Add(1)
+		}
+	}
+	srv.Start()
+	t.Cleanup(srv.Close)
+	return srv, &opened, &closed
+}
+
+// fireQueries 以 concurrency 个并发发送 n 次请求，读完并关闭响应体以便连接回到连接池
+func fireQueries(t *testing.T, datasourceId, url string, n, concurrency int) {
+	t.Helper()
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			res, err := GetDatasourceHTTPClient(datasourceId).Get(url)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			_, _ = io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDatasourceHTTPClientReusesConnections(t *testing.T) {
+	srv, opened, _ := newCountingServer(t)
+	const datasourceId = "pool-reuse"
+	defer RemoveDatasourceHTTPClient(datasourceId)
+
+	if GetDatasourceHTTPClient(datasourceId) != GetDatasourceHTTPClient(datasourceId) {
+		t.Fatal("same datasource must share one client")
+	}
+	if GetDatasourceHTTPClient(datasourceId) == GetDatasourceHTTPClient("pool-other") {
+		t.Fatal("different datasources must not share a client")
+	}
+	RemoveDatasourceHTTPClient("pool-other")
+
+	// 并发数不超过每个数据源保留的空闲连接数，连接应全部被复用
+	const concurrency = 16
+	fireQueries(t, datasourceId, srv.URL, 100, concurrency)
+	first := opened.Load()
+	if first > concurrency {
+		t.Fatalf("100 queries opened %d connections, want at most %d", first, concurrency)
+	}
+
+	fireQueries(t, datasourceId, srv.URL, 100, concurrency)
+	if second := opened.Load() - first; second != 0 {
+		t.Fatalf("second batch opened %d new connections, want all reused", second)
+	}
+}
+
+func TestRemoveDatasourceHTTPClientEvicts(t *testing.T) {
+	srv, opened, closed := newCountingServer(t)
+	const datasourceId = "pool-evict"
+
+	before := GetDatasourceHTTPClient(datasourceId)
+	fireQueries(t, datasourceId, srv.URL, 1, 1)
+	if opened.Load() != 1 {
+		t.Fatalf("opened %d connections, want 1", opened.Load())
+	}
+
+	RemoveDatasourceHTTPClient(datasourceId)
+
+	// 移除时关闭空闲连接
+	deadline := time.Now().Add(2 * time.Second)
+	for closed.Load() < 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if closed.Load() < 1 {
+		t.Fatal("idle connection was not closed on eviction")
+	}
+
+	after := GetDatasourceHTTPClient(datasourceId)
+	defer RemoveDatasourceHTTPClient(datasourceId)
+	if after == before {
+		t.Fatal("evicted client must be replaced by a new one")
+	}
+
+	fireQueries(t, datasourceId, srv.URL, 1, 1)
+	if opened.Load() != 2 {
+		t.Fatalf("opened %d connections after eviction, want 2", opened.Load())
+	}
+}
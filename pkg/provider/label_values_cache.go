@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"sync"
+	"time"
+)
+
+// labelValuesCacheMaxEntries label 值缓存的最大条目数，label 与 metric 组合由请求参数决定，需要上限避免无限增长
+var labelValuesCacheMaxEntries = 10000
+
+// LabelValuesEntry label 值缓存项
+type LabelValuesEntry struct {
+	Values     []string
+	ModifiedAt time.Time // 值集合最近一次变化的时间
+	ExpireAt   time.Time
+}
+
+// labelValuesCache 按数据源 ID 分组的 label 值缓存，便于数据源更新或删除时整体清理
+var labelValuesCache = struct {
+	sync.Mutex
+	entries map[string]map[string]LabelValuesEntry
+	size    int
+}{entries: make(map[string]map[string]LabelValuesEntry)}
+
+// GetLabelValuesCache 获取数据源下指定 key 的缓存项，过期项同样返回，由调用方判断是否可用
+func GetLabelValuesCache(datasourceId, key string) (LabelValuesEntry, bool) {
+	labelValuesCache.Lock()
+	defer labelValuesCache.Unlock()
+
+	entry, ok := labelValuesCache.entries[datasourceId][key]
+	return entry, ok
+}
+
+// SetLabelValuesCache 写入缓存项，达到上限时先清理过期项，仍不足则淘汰最早过期的一项
+func SetLabelValuesCache(datasourceId, key string, entry LabelValuesEntry) {
+	labelValuesCache.Lock()
+	defer labelValuesCache.Unlock()
+
+	group, ok := labelValuesCache.entries[datasourceId]
+	if _, exists := group[key]; !exists && labelValuesCache.size >= labelValuesCacheMaxEntries {
+		sweepLabelValuesCache(time.Now())
+		if labelValuesCache.size >= labelValuesCacheMaxEntries {
+			evictEarliestLabelValues()
+		}
+		group, ok = labelValuesCache.entries[datasourceId]
+	}
+
+	if !ok {
+		group = make(map[string]LabelValuesEntry)
+		labelValuesCache.entries[datasourceId] = group
+	}
+	if _, exists := group[key]; !exists {
+		labelValuesCache.size++
+	}
+	group[key] = entry
+}
+
+// RemoveLabelValuesCache 清理数据源的全部 label 值缓存，数据源更新或删除时调用
+func RemoveLabelValuesCache(datasourceId string) {
+	labelValuesCache.Lock()
+	defer labelValuesCache.Unlock()
+
+	labelValuesCache.size -= len(labelValuesCache.entries[datasourceId])
+	delete(labelValuesCache.entries, datasourceId)
+}
+
+// sweepLabelValuesCache 清理已过期的缓存项，调用方需持有锁
+func sweepLabelValuesCache(now time.Time) {
+	for datasourceId, group := range labelValuesCache.entries {
+		for key, entry := range group {
+			if now.After(entry.ExpireAt) {
+				delete(group, key)
+				labelValuesCache.size--
+			}
+		}
+		if len(group) == 0 {
+			delete(labelValuesCache.entries, datasourceId)
+		}
+	}
+}
+
+// evictEarliestLabelValues 淘汰最早过期的一项，调用方需持有锁
+func evictEarliestLabelValues() {
+	var (
+		victimDs, victimKey string
+		earliest            time.Time
+		found               bool
+	)
+	for datasourceId, group := range labelValuesCache.entries {
+		for key, entry := range group {
+			if !found || entry.ExpireAt.Before(earliest) {
+				victimDs, victimKey, earliest, found = datasourceId, key, entry.ExpireAt, true
+			}
+		}
+	}
+	if !found {
+		return
+	}
+
+	group := labelValuesCache.entries[victimDs]
+	delete(group, victimKey)
+	labelValuesCache.size--
+	if len(group) == 0 {
+		delete(labelValuesCache.entries, victimDs)
+	}
+}
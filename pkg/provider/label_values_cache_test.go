@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// resetLabelValuesCache 清空缓存并设置上限，测试结束后恢复
+func resetLabelValuesCache(t *testing.T, maxEntries int) {
+	t.Helper()
+	reset := func() {
+		labelValuesCache.Lock()
+		labelValuesCache.entries = make(map[string]map[string]LabelValuesEntry)
+		labelValuesCache.size = 0
+		labelValuesCache.Unlock()
+	}
+	reset()
+	original := labelValuesCacheMaxEntries
+	labelValuesCacheMaxEntries = maxEntries
+	t.Cleanup(func() {
+		labelValuesCacheMaxEntries = original
+		reset()
+	})
+}
+
+func TestRemoveLabelValuesCache(t *testing.T) {
+	resetLabelValuesCache(t, 100)
+
+	expireAt := time.Now().Add(time.Minute)
+	SetLabelValuesCache("ds-a", "job|", LabelValuesEntry{Values: []string{"api"}, ExpireAt: expireAt})
+	SetLabelValuesCache("ds-a", "instance|up", LabelValuesEntry{Values: []string{"n1"}, ExpireAt: expireAt})
+	SetLabelValuesCache("ds-b", "job|", LabelValuesEntry{Values: []string{"node"}, ExpireAt: expireAt})
+
+	RemoveLabelValuesCache("ds-a")
+
+	if _, ok := GetLabelValuesCache("ds-a", "job|"); ok {
+		t.Fatal("ds-a 的缓存应已清理")
+	}
+	if _, ok := GetLabelValuesCache("ds-a", "instance|up"); ok {
+		t.Fatal("ds-a 的缓存应已清理")
+	}
+	if entry, ok := GetLabelValuesCache("ds-b", "job|"); !ok || entry.Values[0] != "node" {
+		t.Fatalf("ds-b 的缓存不应受影响，got %+v, %v", entry, ok)
+	}
+	if labelValuesCache.size != 1 {
+		t.Fatalf("size got %d, want 1", labelValuesCache.size)
+	}
+}
+
+func TestSetLabelValuesCacheBounded(t *testing.T) {
+	resetLabelValuesCache(t, 3)
+
+	now := time.Now()
+	SetLabelValuesCache("ds", "expired|", LabelValuesEntry{ExpireAt: now.Add(-time.Second)})
+	SetLabelValuesCache("ds", "early|", LabelValuesEntry{ExpireAt: now.Add(time.Minute)})
+	SetLabelValuesCache("ds", "late|", LabelValuesEntry{ExpireAt: now.Add(time.Hour)})
+
+	// 达到上限时优先清理过期项
+	SetLabelValuesCache("ds", "new1|", LabelValuesEntry{ExpireAt: now.Add(time.Hour)})
+	if _, ok := GetLabelValuesCache("ds", "expired|"); ok {
+		t.Fatal("过期项应被清理")
+	}
+	if _, ok := GetLabelValuesCache("ds", "early|"); !ok {
+		t.Fatal("未过期项不应被清理")
+	}
+
+	// 没有过期项时淘汰最早过期的一项
+	SetLabelValuesCache("ds-other", "new2|", LabelValuesEntry{ExpireAt: now.Add(time.Hour)})
+	if _, ok := GetLabelValuesCache("ds", "early|"); ok {
+		t.Fatal("最早过期的项应被淘汰")
+	}
+
+	// 覆盖已有 key 不触发淘汰
+	SetLabelValuesCache("ds", "late|", LabelValuesEntry{ExpireAt: now.Add(2 * time.Hour)})
+	for _, key := range []string{"late|", "new1|"} {
+		if _, ok := GetLabelValuesCache("ds", key); !ok {
+			t.Fatalf("%s 不应被淘汰", key)
+		}
+	}
+
+	// 大量不同 key 写入后总数不超过上限
+	for i := 0; i < 50; i++ {
+		SetLabelValuesCache(fmt.Sprintf("ds-%d", i%5), fmt.Sprintf("job|m%d", i), LabelValuesEntry{ExpireAt: now.Add(time.Duration(i) * time.Second)})
+	}
+	count := 0
+	for _, group := range labelValuesCache.entries {
+		count += len(group)
+	}
+	if count != 3 || labelValuesCache.size != 3 {
+		t.Fatalf("entries got %d (size %d), want 3", count, labelValuesCache.size)
+	}
+}
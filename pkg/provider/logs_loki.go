@@ -4,11 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"github.com/bytedance/sonic"
 	"github.com/zeromicro/go-zero/core/logc"
 	"net/http"
 	"net/url"
-	"strconv"
 	"time"
 	"alertHub/internal/models"
 	"alertHub/pkg/tools"
@@ -57,21 +57,32 @@ func (l LokiProvider) Query(options LogQueryOptions) (Logs, int, error) {
 		options.Loki.Limit = 100
 	}
 
-	if options.StartAt == "" {
-		duration, _ := time.ParseDuration(strconv.Itoa(1) + "h")
-		options.StartAt = curTime.Add(-duration).Format(time.RFC3339Nano)
+	// 未指定时间范围时默认查询最近 1 小时，Loki 支持秒级 Unix 时间戳
+	if options.StartAt == "" || options.StartAt == nil {
+		options.StartAt = curTime.Add(-time.Hour).Unix()
 	}
 
-	if options.EndAt == "" {
-		options.EndAt = curTime.Format(time.RFC3339Nano)
+	if options.EndAt == "" || options.EndAt == nil {
+		options.EndAt = curTime.Unix()
+	}
+
+	timeout := int(l.timeout)
+	if timeout <= 0 {
+		timeout = 10
 	}
 
 	args := fmt.Sprintf("/loki/api/v1/query_range?query=%s&direction=%s&limit=%d&start=%d&end=%d", url.QueryEscape(options.Loki.Query), options.Loki.Direction, options.Loki.Limit, options.StartAt.(int64), options.EndAt.(int64))
 	requestURL := l.url + args
-	res, err := tools.Get(nil, requestURL, 10)
+	res, err := tools.Get(nil, requestURL, timeout)
 	if err != nil {
 		return Logs{}, 0, err
 	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(res.Body)
+		return Logs{}, 0, fmt.Errorf("查询Loki失败, status: %d, body: %s", res.StatusCode, string(body))
+	}
 
 	var resultData result
 	if err := tools.ParseReaderBody(res.Body, &resultData); err != nil {
@@ -117,11 +128,10 @@ func (l LokiProvider) Query(options LogQueryOptions) (Logs, int, error) {
 				continue
 			}
 
+			// 非 JSON 格式的日志行保留原文，与其他日志数据源的结果结构保持一致
 			var msg map[string]interface{}
-			err := sonic.Unmarshal(jsonData, &msg)
-			if err != nil {
-				logc.Error(context.Background(), fmt.Sprintf("解析 Loki 日志数据错误, %v", string(jsonData)))
-				continue
+			if err := sonic.Unmarshal(jsonData, &msg); err != nil || msg == nil {
+				msg = map[string]interface{}{"message": string(jsonData)}
 			}
 			message = append(message, msg)
 		}
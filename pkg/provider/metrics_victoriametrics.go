@@ -30,8 +30,10 @@ func NewVictoriaMetricsClient(ds models.AlertDataSource) (MetricsFactoryProvider
 }
 
 type QueryResponse struct {
-	Status string `json:"status"`
-	VMData VMData `json:"data"`
+	Status    string `json:"status"`
+	VMData    VMData `json:"data"`
+	ErrorType string `json:"errorType,omitempty"`
+	Error     string `json:"error,omitempty"`
 }
 
 type VMData struct {
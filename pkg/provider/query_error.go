@@ -0,0 +1,141 @@
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// QueryErrorCategory 查询失败类型，不同类型对应不同的排查方向
+type QueryErrorCategory string
+
+const (
+	// QueryErrorTimeout 查询超时，通常是查询过于昂贵或后端负载过高
+	QueryErrorTimeout QueryErrorCategory = "timeout"
+	// QueryErrorConnection 连接失败，通常是后端不可用或网络不通
+	QueryErrorConnection QueryErrorCategory = "connection"
+	// QueryErrorTLS TLS 握手或证书校验失败，通常是协议或证书配置错误
+	QueryErrorTLS QueryErrorCategory = "tls"
+	// QueryErrorSyntax 查询语句错误，重试无意义
+	QueryErrorSyntax QueryErrorCategory = "query_syntax"
+	// QueryErrorAuth 认证或鉴权失败，重试无意义
+	QueryErrorAuth QueryErrorCategory = "auth"
+	// QueryErrorServer 后端内部错误
+	QueryErrorServer QueryErrorCategory = "server"
+	// QueryErrorUnknown 无法识别的错误
+	QueryErrorUnknown QueryErrorCategory = "unknown"
+)
+
+// queryErrorCategoryDesc 错误类型的中文描述，用于错误信息前缀
+var queryErrorCategoryDesc = map[QueryErrorCategory]string{
+	QueryErrorTimeout:    "查询超时",
+	QueryErrorConnection: "连接失败",
+	QueryErrorTLS:        "TLS 握手失败",
+	QueryErrorSyntax:     "查询语句错误",
+	QueryErrorAuth:       "认证失败",
+	QueryErrorServer:     "服务端错误",
+	QueryErrorUnknown:    "未知错误",
+}
+
+// QueryError 分类后的查询错误
+type QueryError struct {
+	Category   QueryErrorCategory
+	StatusCode int
+	Err        error
+}
+
+func (e *QueryError) Error() string {
+	return fmt.Sprintf("[%s] %s", queryErrorCategoryDesc[e.Category], e.Err.Error())
+}
+
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// GetQueryErrorCategory 获取错误的分类，未分类的错误返回 QueryErrorUnknown
+func GetQueryErrorCategory(err error) QueryErrorCategory {
+	var qe *QueryError
+	if errors.As(err, &qe) {
+		return qe.Category
+	}
+	return QueryErrorUnknown
+}
+
+// ClassifyRequestError 对请求阶段（未拿到响应）的错误进行分类
+func ClassifyRequestError(err error) *QueryError {
+	if err == nil {
+		return nil
+	}
+
+	category := QueryErrorUnknown
+	var netErr net.Error
+	var opErr *net.OpError
+	var dnsErr *net.DNSError
+	var recordErr tls.RecordHeaderError
+	var certErr *tls.CertificateVerificationError
+	var authorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		category = QueryErrorTimeout
+	case errors.As(err, &netErr) && netErr.Timeout():
+		category = QueryErrorTimeout
+	case errors.As(err, &recordErr), errors.As(err, &certErr), errors.As(err, &authorityErr), errors.As(err, &hostnameErr),
+		// 以 https 访问 http 服务时 net/http 只返回该文本错误
+		strings.Contains(err.Error(), "server gave HTTP response to HTTPS client"):
+		category = QueryErrorTLS
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EHOSTUNREACH):
+		category = QueryErrorConnection
+	case errors.As(err, &dnsErr), errors.As(err, &opErr):
+		category = QueryErrorConnection
+	case strings.Contains(strings.ToLower(err.Error()), "timeout"):
+		category = QueryErrorTimeout
+	}
+
+	return &QueryError{Category: category, Err: err}
+}
+
+// ClassifyResponseError 根据 HTTP 状态码和 Prometheus 返回的 errorType 对查询错误进行分类
+// errorType 参考 Prometheus HTTP API: timeout/canceled/execution/bad_data/internal/unavailable
+func ClassifyResponseError(statusCode int, errorType string, err error) *QueryError {
+	category := QueryErrorUnknown
+	switch errorType {
+	case "timeout", "canceled":
+		category = QueryErrorTimeout
+	case "bad_data":
+		category = QueryErrorSyntax
+	case "unavailable":
+		category = QueryErrorConnection
+	case "internal":
+		category = QueryErrorServer
+	}
+
+	if category == QueryErrorUnknown {
+		switch {
+		case statusCode == http.StatusUnauthorized, statusCode == http.StatusForbidden:
+			category = QueryErrorAuth
+		case statusCode == http.StatusBadRequest, statusCode == http.StatusUnprocessableEntity:
+			category = QueryErrorSyntax
+		case statusCode == http.StatusGatewayTimeout, statusCode == http.StatusRequestTimeout:
+			category = QueryErrorTimeout
+		case statusCode == http.StatusBadGateway, statusCode == http.StatusServiceUnavailable:
+			category = QueryErrorConnection
+		case statusCode >= http.StatusInternalServerError:
+			category = QueryErrorServer
+		}
+	}
+
+	return &QueryError{Category: category, StatusCode: statusCode, Err: err}
+}
+
+// ClassifyParseError 对响应体无法解析的错误进行分类，响应格式异常视为服务端错误
+func ClassifyParseError(statusCode int, err error) *QueryError {
+	return &QueryError{Category: QueryErrorServer, StatusCode: statusCode, Err: err}
+}
@@ -0,0 +1,100 @@
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+)
+
+// urlErr 按 net/http 客户端的方式包装请求错误
+func urlErr(err error) error {
+	return &url.Error{Op: "Get", URL: "http://prom:9090/api/v1/query", Err: err}
+}
+
+func TestClassifyRequestError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want QueryErrorCategory
+	}{
+		{"DNS 解析失败", urlErr(&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", Name: "prom", IsNotFound: true}}), QueryErrorConnection},
+		{"连接被拒绝", urlErr(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), QueryErrorConnection},
+		{"连接被重置", urlErr(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), QueryErrorConnection},
+		{"context 超时", urlErr(context.DeadlineExceeded), QueryErrorTimeout},
+		{"读超时", urlErr(&net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}), QueryErrorTimeout},
+		{"TLS 记录头错误", urlErr(tls.RecordHeaderError{Msg: "first record does not look like a TLS handshake"}), QueryErrorTLS},
+		{"证书不受信任", urlErr(&tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}), QueryErrorTLS},
+		{"以 https 访问 http 服务", urlErr(errors.New("http: server gave HTTP response to HTTPS client")), QueryErrorTLS},
+		{"未知错误", errors.New("something else"), QueryErrorUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qe := ClassifyRequestError(fmt.Errorf("请求Prometheus失败: %w", tt.err))
+			if qe.Category != tt.want {
+				t.Fatalf("got %s, want %s", qe.Category, tt.want)
+			}
+			if !errors.Is(qe, tt.err) {
+				t.Fatal("classified error must wrap the original error")
+			}
+			if GetQueryErrorCategory(fmt.Errorf("wrapped: %w", qe)) != tt.want {
+				t.Fatal("category must survive wrapping")
+			}
+		})
+	}
+
+	if ClassifyRequestError(nil) != nil {
+		t.Fatal("nil error must stay nil")
+	}
+}
+
+func TestClassifyResponseError(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		errorType  string
+		want       QueryErrorCategory
+	}{
+		{"语句错误 bad_data", http.StatusBadRequest, "bad_data", QueryErrorSyntax},
+		{"执行错误 422", http.StatusUnprocessableEntity, "execution", QueryErrorSyntax},
+		{"400 无 errorType", http.StatusBadRequest, "", QueryErrorSyntax},
+		{"401 未认证", http.StatusUnauthorized, "", QueryErrorAuth},
+		{"403 无权限", http.StatusForbidden, "", QueryErrorAuth},
+		{"查询超时 timeout", http.StatusServiceUnavailable, "timeout", QueryErrorTimeout},
+		{"查询取消 canceled", http.StatusServiceUnavailable, "canceled", QueryErrorTimeout},
+		{"网关超时 504", http.StatusGatewayTimeout, "", QueryErrorTimeout},
+		{"后端不可用 unavailable", http.StatusServiceUnavailable, "unavailable", QueryErrorConnection},
+		{"网关错误 502", http.StatusBadGateway, "", QueryErrorConnection},
+		{"内部错误 internal", http.StatusInternalServerError, "internal", QueryErrorServer},
+		{"500 无 errorType", http.StatusInternalServerError, "", QueryErrorServer},
+		{"200 但状态为 error", http.StatusOK, "timeout", QueryErrorTimeout},
+		{"404 无法识别", http.StatusNotFound, "", QueryErrorUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qe := ClassifyResponseError(tt.statusCode, tt.errorType, errors.New("query failed"))
+			if qe.Category != tt.want {
+				t.Fatalf("got %s, want %s", qe.Category, tt.want)
+			}
+			if qe.StatusCode != tt.statusCode {
+				t.Fatalf("status got %d, want %d", qe.StatusCode, tt.statusCode)
+			}
+		})
+	}
+}
+
+func TestClassifyParseError(t *testing.T) {
+	qe := ClassifyParseError(http.StatusOK, errors.New("invalid character '<'"))
+	if qe.Category != QueryErrorServer || qe.StatusCode != http.StatusOK {
+		t.Fatalf("got %s/%d, want server/200", qe.Category, qe.StatusCode)
+	}
+}
@@ -58,7 +58,28 @@ func Sender(ctx *ctx.Context, sendParams SendParams) error {
 		return fmt.Errorf("Send alarm failed, %s", err.Error())
 	}
 
-	// 发送通知
+	return rateLimitedSend(ctx, sender, sendParams)
+}
+
+// rateLimitedSend 按渠道限速发送通知
+// 超出平台限制的消息在当前调用中排队等待，返回值为真实的发送结果；排队超过上限时直接记录失败
+func rateLimitedSend(ctx *ctx.Context, sender SendInter, sendParams SendParams) error {
+	delay, err := reserveRateLimit(sendParams)
+	if err != nil {
+		addRecord(ctx, sendParams, 1, sendParams.Content, err.Error())
+		return fmt.Errorf("Send alarm failed to %s, err: %s", sendParams.NoticeType, err.Error())
+	}
+
+	if delay > 0 {
+		logc.Infof(ctx.Ctx, "通知渠道 %s 触发限速，等待 %s 后发送", sendParams.NoticeType, delay)
+		time.Sleep(delay)
+	}
+
+	return send(ctx, sender, sendParams)
+}
+
+// send 发送通知并记录发送结果
+func send(ctx *ctx.Context, sender SendInter, sendParams SendParams) error {
 	if err := sender.Send(sendParams); err != nil {
 		addRecord(ctx, sendParams, 1, sendParams.Content, err.Error())
 		return fmt.Errorf("Send alarm failed to %s, err: %s", sendParams.NoticeType, err.Error())
@@ -102,6 +123,10 @@ func senderFactory(noticeType string) (SendInter, error) {
 		return NewPhoneCallSender(), nil
 	case "Slack":
 		return NewSlackSender(), nil
+	case "Teams":
+		return NewTeamsSender(), nil
+	case "Telegram":
+		return NewTelegramSender(), nil
 	case "SMS":
 		return NewSmsSender(), nil
 	default:
@@ -140,7 +165,7 @@ func (s *SendParams) GetSendMsg() map[string]any {
 	}
 	err := sonic.Unmarshal([]byte(s.Content), &msg)
 	if err != nil {
-		logc.Errorf(ctx.Ctx, fmt.Sprintf("发送的内容解析失败, err: %s", err.Error()))
+		logc.Errorf(ctx.Ctx, "发送的内容解析失败, err: %s", err.Error())
 		return msg
 	}
 	return msg
@@ -50,7 +50,7 @@ func (f *FeiShuSender) Test(params SendParams) error {
 	msg := make(map[string]any)
 	err := sonic.Unmarshal([]byte(testContent), &msg)
 	if err != nil {
-		logc.Errorf(ctx.Ctx, fmt.Sprintf("发送的内容解析失败, err: %s", err.Error()))
+		logc.Errorf(ctx.Ctx, "发送的内容解析失败, err: %s", err.Error())
 		return err
 	}
 
@@ -0,0 +1,91 @@
+package sender
+
+import (
+	"alertHub/config"
+	"alertHub/internal/global"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// defaultNoticeRateLimits 各通知类型每分钟允许发送的次数（按机器人/Webhook 维度）
+// 参考各平台公开的限流策略，未列出的类型默认不限流
+var defaultNoticeRateLimits = map[string]int{
+	"dingding": 20,
+	"feishu":   100,
+	"wechat":   20,
+	"slack":    60,
+}
+
+// channelLimiters 按通知渠道缓存的令牌桶，key 为 通知类型 + 渠道地址
+var channelLimiters sync.Map
+
+// errNoticeQueueFull 渠道排队已超过最长等待时间
+var errNoticeQueueFull = errors.New("通知渠道限速排队已满")
+
+// reserveRateLimit 为消息预留目标渠道的发送令牌，返回距离可发送还需等待的时长
+// 令牌按预留顺序依次发放；需等待的时长超过 MaxWait 时归还令牌并返回错误，避免排队无限增长
+func reserveRateLimit(sendParams SendParams) (time.Duration, error) {
+	cfg := global.Config.NoticeRateLimit
+	if !cfg.IsEnabled() {
+		return 0, nil
+	}
+
+	limiter := getChannelLimiter(cfg, sendParams)
+	if limiter == nil {
+		return 0, nil
+	}
+
+	r := limiter.Reserve()
+	delay := r.Delay()
+	if maxWait := cfg.GetMaxWait(); delay > maxWait {
+		r.Cancel()
+		return 0, fmt.Errorf("%w, 需等待 %s, 超过上限 %s", errNoticeQueueFull, delay.Round(time.Second), maxWait)
+	}
+
+	return delay, nil
+}
+
+// getChannelLimiter 获取渠道对应的令牌桶，未配置限速的通知类型返回 nil
+func getChannelLimiter(cfg config.NoticeRateLimit, sendParams SendParams) *rate.Limiter {
+	perMinute := getNoticeRateLimit(cfg, sendParams.NoticeType)
+	if perMinute <= 0 {
+		return nil
+	}
+
+	// 平台限流以机器人为单位，优先使用 Hook 地址区分渠道
+	channel := sendParams.Hook
+	if channel == "" {
+		channel = sendParams.NoticeId
+	}
+	key := fmt.Sprintf("%s:%s", strings.ToLower(sendParams.NoticeType), channel)
+
+	// 令牌桶容量为 1，使消息均匀间隔发送，任意一分钟窗口内都不会超过平台限制
+	limit := rate.Every(time.Minute / time.Duration(perMinute))
+	v, loaded := channelLimiters.LoadOrStore(key, rate.NewLimiter(limit, 1))
+	limiter := v.(*rate.Limiter)
+
+	// 限速配置变更后复用原令牌桶，只调整速率
+	if loaded && limiter.Limit() != limit {
+		limiter.SetLimit(limit)
+	}
+
+	return limiter
+}
+
+// getNoticeRateLimit 获取通知类型的每分钟限速，配置优先于内置默认值
+// viper 会将 map 的 key 转为小写，这里统一按小写匹配
+func getNoticeRateLimit(cfg config.NoticeRateLimit, noticeType string) int {
+	noticeType = strings.ToLower(noticeType)
+	for k, v := range cfg.Limits {
+		if strings.ToLower(k) == noticeType {
+			return v
+		}
+	}
+
+	return defaultNoticeRateLimits[noticeType]
+}
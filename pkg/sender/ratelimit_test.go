@@ -0,0 +1,253 @@
+package sender
+
+import (
+	"alertHub/config"
+	"alertHub/internal/ctx"
+	"alertHub/internal/global"
+	"alertHub/internal/models"
+	"alertHub/internal/repo"
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+func setNoticeRateLimit(t *testing.T, cfg config.NoticeRateLimit) {
+	t.Helper()
+	old := global.Config.NoticeRateLimit
+	global.Config.NoticeRateLimit = cfg
+	// 令牌桶为包级状态，重复运行时需清空，避免沿用上一次的排队进度
+	channelLimiters.Range(func(k, _ any) bool {
+		channelLimiters.Delete(k)
+		return true
+	})
+	t.Cleanup(func() { global.Config.NoticeRateLimit = old })
+}
+
+func boolPtr(b bool) *bool { return &b }
+
+func TestReserveRateLimitDisabled(t *testing.T) {
+	setNoticeRateLimit(t, config.NoticeRateLimit{Enabled: boolPtr(false)})
+
+	params := SendParams{NoticeType: "DingDing", Hook: "https://example.com/disabled"}
+	for i := 0; i < 5; i++ {
+		if delay, _ := reserveRateLimit(params); delay != 0 {
+			t.Fatalf("限流关闭时不应延迟, got %s", delay)
+		}
+	}
+}
+
+func TestReserveRateLimitDefersInsteadOfDropping(t *testing.T) {
+	setNoticeRateLimit(t, config.NoticeRateLimit{Limits: map[string]int{"dingding": 60}})
+
+	params := SendParams{NoticeType: "DingDing", Hook: "https://example.com/defer"}
+	if delay, _ := reserveRateLimit(params); delay != 0 {
+		t.Fatalf("首条消息应立即发送, got %s", delay)
+	}
+
+	// 后续消息依次排队，每条间隔 1s
+	for i := 1; i <= 3; i++ {
+		delay, err := reserveRateLimit(params)
+		if err != nil {
+			t.Fatalf("第 %d 条排队消息不应超过排队上限, err: %v", i, err)
+		}
+		want := time.Duration(i) * time.Second
+		if delay < want-100*time.Millisecond || delay > want {
+			t.Fatalf("第 %d 条排队消息延迟应约为 %s, got %s", i, want, delay)
+		}
+	}
+}
+
+func TestReserveRateLimitUnlimitedType(t *testing.T) {
+	setNoticeRateLimit(t, config.NoticeRateLimit{})
+
+	params := SendParams{NoticeType: "Email", NoticeId: "n-unlimited"}
+	for i := 0; i < 5; i++ {
+		if delay, _ := reserveRateLimit(params); delay != 0 {
+			t.Fatalf("未配置限速的通知类型不应延迟, got %s", delay)
+		}
+	}
+}
+
+func TestGetChannelLimiterReusedAcrossConfigChange(t *testing.T) {
+	params := SendParams{NoticeType: "FeiShu", Hook: "https://example.com/reuse"}
+
+	first := getChannelLimiter(config.NoticeRateLimit{Limits: map[string]int{"feishu": 60}}, params)
+	second := getChannelLimiter(config.NoticeRateLimit{Limits: map[string]int{"feishu": 120}}, params)
+	if first != second {
+		t.Fatal("限速配置变更后应复用同一令牌桶")
+	}
+	if want := 2.0; float64(second.Limit()) != want {
+		t.Fatalf("令牌桶速率应更新为 %v/s, got %v", want, second.Limit())
+	}
+
+	other := getChannelLimiter(config.NoticeRateLimit{}, SendParams{NoticeType: "FeiShu", Hook: "https://example.com/other"})
+	if other == first {
+		t.Fatal("不同渠道不应共享令牌桶")
+	}
+}
+
+func TestGetNoticeRateLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		limits     map[string]int
+		noticeType string
+		want       int
+	}{
+		{"内置默认值", nil, "DingDing", 20},
+		{"配置覆盖默认值", map[string]int{"dingding": 5}, "DingDing", 5},
+		{"配置大小写不敏感", map[string]int{"Slack": 10}, "slack", 10},
+		{"配置为 0 表示不限流", map[string]int{"wechat": 0}, "WeChat", 0},
+		{"未知类型不限流", nil, "Email", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := getNoticeRateLimit(config.NoticeRateLimit{Limits: tt.limits}, tt.noticeType)
+			if got != tt.want {
+				t.Fatalf("got %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReserveRateLimitRejectsPastMaxWait(t *testing.T) {
+	setNoticeRateLimit(t, config.NoticeRateLimit{MaxWait: 1, Limits: map[string]int{"dingding": 60}})
+
+	params := SendParams{NoticeType: "DingDing", Hook: "https://example.com/max-wait"}
+	if delay, err := reserveRateLimit(params); err != nil || delay != 0 {
+		t.Fatalf("首条消息应立即发送, delay: %s, err: %v", delay, err)
+	}
+	if _, err := reserveRateLimit(params); err != nil {
+		t.Fatalf("等待 1s 未超过上限, 不应返回错误: %v", err)
+	}
+
+	// 超过上限的预留会归还令牌，后续消息同样被拒绝而不是继续累积排队
+	for i := 0; i < 3; i++ {
+		if _, err := reserveRateLimit(params); !errors.Is(err, errNoticeQueueFull) {
+			t.Fatalf("排队超过上限应返回 errNoticeQueueFull, got %v", err)
+		}
+	}
+}
+
+func TestRateLimitedSendSpacesSends(t *testing.T) {
+	// 每分钟 600 条，即每 100ms 发送一条
+	setNoticeRateLimit(t, config.NoticeRateLimit{Limits: map[string]int{"dingding": 600}})
+
+	const total = 5
+	interval := 100 * time.Millisecond
+	fs := &fakeSender{}
+	repo := &fakeNoticeRecordRepo{}
+	c := newTestContext(repo)
+	params := SendParams{NoticeType: "DingDing", Hook: "https://example.com/spacing"}
+
+	var wg sync.WaitGroup
+	for i := 0; i < total; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := rateLimitedSend(c, fs, params); err != nil {
+				t.Errorf("排队消息应发送成功, err: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	sent := fs.sentAt()
+	if len(sent) != total {
+		t.Fatalf("应发送 %d 条消息, got %d", total, len(sent))
+	}
+	sort.Slice(sent, func(i, j int) bool { return sent[i].Before(sent[j]) })
+	for i := 1; i < len(sent); i++ {
+		// 允许少量调度误差
+		if gap := sent[i].Sub(sent[i-1]); gap < interval-10*time.Millisecond {
+			t.Fatalf("第 %d 与第 %d 条消息间隔 %s, 应不小于 %s", i, i+1, gap, interval)
+		}
+	}
+	if got := repo.statuses(); len(got) != total || got[0] != 0 {
+		t.Fatalf("每条消息都应记录发送成功, got %v", got)
+	}
+}
+
+func TestRateLimitedSendFailsPastMaxWait(t *testing.T) {
+	setNoticeRateLimit(t, config.NoticeRateLimit{MaxWait: 1, Limits: map[string]int{"dingding": 1}})
+
+	fs := &fakeSender{}
+	repo := &fakeNoticeRecordRepo{}
+	c := newTestContext(repo)
+	params := SendParams{NoticeType: "DingDing", Hook: "https://example.com/queue-full"}
+
+	if err := rateLimitedSend(c, fs, params); err != nil {
+		t.Fatalf("首条消息应发送成功, err: %v", err)
+	}
+
+	// 第二条需等待 1 分钟，超过 1s 上限，应立即返回错误且不发送
+	start := time.Now()
+	err := rateLimitedSend(c, fs, params)
+	if err == nil {
+		t.Fatal("排队超过上限应返回错误")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("超过上限的消息不应等待, elapsed %s", elapsed)
+	}
+	if n := len(fs.sentAt()); n != 1 {
+		t.Fatalf("超过上限的消息不应发送, sent %d", n)
+	}
+	if got := repo.statuses(); len(got) != 2 || got[1] != 1 {
+		t.Fatalf("超过上限的消息应记录发送失败, got %v", got)
+	}
+}
+
+// fakeSender 记录每次发送时间的发送器
+type fakeSender struct {
+	mu   sync.Mutex
+	sent []time.Time
+}
+
+func (f *fakeSender) Send(SendParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.sent = append(f.sent, time.Now())
+	return nil
+}
+
+func (f *fakeSender) Test(SendParams) error { return nil }
+
+func (f *fakeSender) sentAt() []time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]time.Time(nil), f.sent...)
+}
+
+// fakeNoticeRecordRepo 只实现通知记录写入，其余方法未使用
+type fakeNoticeRecordRepo struct {
+	repo.InterEntryRepo
+	repo.InterNoticeRepo
+	mu      sync.Mutex
+	records []models.NoticeRecord
+}
+
+func (f *fakeNoticeRecordRepo) Notice() repo.InterNoticeRepo { return f }
+
+func (f *fakeNoticeRecordRepo) AddRecord(r models.NoticeRecord) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.records = append(f.records, r)
+	return nil
+}
+
+func (f *fakeNoticeRecordRepo) statuses() []int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []int
+	for _, r := range f.records {
+		out = append(out, r.Status)
+	}
+	return out
+}
+
+func newTestContext(r repo.InterEntryRepo) *ctx.Context {
+	return &ctx.Context{DB: r, Ctx: context.Background()}
+}
@@ -0,0 +1,48 @@
+package sender
+
+import (
+	"alertHub/internal/models"
+	"alertHub/pkg/tools"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+type (
+	// TeamsSender Microsoft Teams 发送策略
+	TeamsSender struct{}
+)
+
+func NewTeamsSender() SendInter {
+	return &TeamsSender{}
+}
+
+func (t *TeamsSender) Send(params SendParams) error {
+	return t.post(params.Hook, params.Content)
+}
+
+func (t *TeamsSender) Test(params SendParams) error {
+	msg := models.NewTeamsMsgTemplate("AlertHub")
+	msg.Text = RobotTestContent
+	return t.post(params.Hook, tools.JsonMarshalToString(msg))
+}
+
+func (t *TeamsSender) post(hook, content string) error {
+	res, err := tools.Post(nil, hook, bytes.NewReader([]byte(content)), 10)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	// Incoming Webhook 返回 200，Workflows 返回 202
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		bodyByte, err := io.ReadAll(res.Body)
+		if err != nil {
+			return fmt.Errorf("读取 Body 失败, err: %s", err.Error())
+		}
+		return errors.New(string(bodyByte))
+	}
+
+	return nil
+}
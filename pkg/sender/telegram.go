@@ -0,0 +1,57 @@
+package sender
+
+import (
+	"alertHub/pkg/tools"
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/bytedance/sonic"
+)
+
+type (
+	// TelegramSender Telegram Bot 发送策略
+	// Hook 为完整的 sendMessage 地址，例如：https://api.telegram.org/bot<token>/sendMessage?chat_id=<chatId>
+	TelegramSender struct{}
+
+	telegramResponse struct {
+		Ok          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+)
+
+func NewTelegramSender() SendInter {
+	return &TelegramSender{}
+}
+
+func (t *TelegramSender) Send(params SendParams) error {
+	return t.post(params.Hook, params.Content)
+}
+
+func (t *TelegramSender) Test(params SendParams) error {
+	return t.post(params.Hook, tools.JsonMarshalToString(map[string]string{"text": RobotTestContent}))
+}
+
+func (t *TelegramSender) post(hook, content string) error {
+	res, err := tools.Post(nil, hook, bytes.NewReader([]byte(content)), 10)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	bodyByte, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("读取 Body 失败, err: %s", err.Error())
+	}
+
+	var response telegramResponse
+	if err := sonic.Unmarshal(bodyByte, &response); err != nil {
+		return fmt.Errorf("解析 Telegram 响应失败, err: %s", err.Error())
+	}
+
+	if !response.Ok {
+		return fmt.Errorf("Telegram 发送失败: %s", response.Description)
+	}
+
+	return nil
+}
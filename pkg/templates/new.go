@@ -25,6 +25,10 @@ func NewTemplate(ctx *ctx.Context, alert models.AlertCurEvent, notice models.Ale
 		return Template{CardContentMsg: phoneCallTemplate(alert, noticeTmpl)}
 	case "Slack":
 		return Template{slackTemplate(alert, noticeTmpl)}
+	case "Teams":
+		return Template{CardContentMsg: teamsTemplate(alert, noticeTmpl)}
+	case "Telegram":
+		return Template{CardContentMsg: telegramTemplate(alert, noticeTmpl)}
 	}
 
 	return Template{}
@@ -0,0 +1,17 @@
+package templates
+
+import (
+	"alertHub/internal/models"
+	"alertHub/pkg/tools"
+)
+
+func teamsTemplate(alert models.AlertCurEvent, noticeTmpl models.NoticeTemplateExample) string {
+	t := models.NewTeamsMsgTemplate(alert.RuleName)
+	t.ThemeColor = "FF0000"
+	if alert.IsRecovered {
+		t.ThemeColor = "2EB886"
+	}
+	t.Text = ParserTemplate("Event", alert, noticeTmpl.Template)
+
+	return tools.JsonMarshalToString(t)
+}
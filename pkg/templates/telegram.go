@@ -0,0 +1,14 @@
+package templates
+
+import (
+	"alertHub/internal/models"
+	"alertHub/pkg/tools"
+)
+
+// telegramTemplate 告警模板内容按纯文本发送，避免模板中的字符触发 MarkdownV2 解析错误
+func telegramTemplate(alert models.AlertCurEvent, noticeTmpl models.NoticeTemplateExample) string {
+	return tools.JsonMarshalToString(map[string]interface{}{
+		"text":                     ParserTemplate("Event", alert, noticeTmpl.Template),
+		"disable_web_page_preview": true,
+	})
+}